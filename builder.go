@@ -1,5 +1,13 @@
 package sqlbuilder
 
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
 // Builder Builder接口
 // 在每个实现builder接口的结构体中，执行build方法，将会返回构建的sql和data
 type Builder interface {
@@ -8,6 +16,12 @@ type Builder interface {
 
 type BuilderFunc func() Builder
 
+// Statement 构建好的sql语句及参数
+type Statement struct {
+	SQL  string
+	Args []interface{}
+}
+
 type Column string
 
 type RawExpr struct {
@@ -25,3 +39,73 @@ func Raw(expr string, data ...interface{}) *RawExpr {
 func (r *RawExpr) Build() (string, []interface{}) {
 	return r.expr, r.data
 }
+
+// interpolate 将?占位符替换为参数值，用于调试输出
+func interpolate(sql string, data []interface{}) string {
+	index := 0
+	newSql := make([]rune, 0, len(sql))
+	getData := func(data []interface{}, index int) string {
+		if index > (len(data) - 1) {
+			return ""
+		}
+
+		datum := data[index]
+
+		v := reflect.ValueOf(datum)
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return strconv.Itoa(int(v.Int()))
+		case reflect.String:
+			return "\"" + strings.ReplaceAll(strings.ReplaceAll(v.String(), "\\", "\\\\"), "\"", "\\\"") + "\""
+		case reflect.Bool:
+			if v.Bool() {
+				return "1"
+			}
+			return "0"
+		default:
+			return ""
+		}
+	}
+
+	for _, sqlRune := range sql {
+		if sqlRune == rune('?') {
+			// 将rune替换成 值
+			repData := getData(data, index)
+			newSql = append(newSql, []rune(repData)...)
+			index++
+		} else {
+			newSql = append(newSql, sqlRune)
+		}
+	}
+	return string(newSql)
+}
+
+// sortedKeys 按字段名排序，保证map构建的sql顺序固定
+func sortedKeys(mapData map[string]interface{}) []string {
+	keys := make([]string, 0, len(mapData))
+	for key := range mapData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortValues 对值排序，保证构建的sql顺序固定，同类型的数字、字符串按大小排序，其他按格式化后的字符串排序
+func sortValues(values []interface{}) {
+	sort.SliceStable(values, func(i, j int) bool {
+		a, b := reflect.ValueOf(values[i]), reflect.ValueOf(values[j])
+		if a.Kind() == b.Kind() {
+			switch a.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				return a.Int() < b.Int()
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				return a.Uint() < b.Uint()
+			case reflect.Float32, reflect.Float64:
+				return a.Float() < b.Float()
+			case reflect.String:
+				return a.String() < b.String()
+			}
+		}
+		return fmt.Sprint(values[i]) < fmt.Sprint(values[j])
+	})
+}
@@ -1,18 +1,27 @@
 package sqlbuilder
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"reflect"
-	"strconv"
 	"strings"
 )
 
 type DeleteBuilder struct {
-	isBuilt bool
-	sql     string
-	table   string
-	where   *WhereBuilder
-	data    []interface{}
+	isBuilt   bool
+	sql       string
+	table     string
+	where     *WhereBuilder
+	join      []*Join
+	order     []*orderStat
+	limit     int
+	allRows   bool
+	targets   []string
+	dialect   Dialect
+	quote     *bool
+	err       error
+	returning []string
+	data      []interface{}
 }
 
 func Delete(table string) *DeleteBuilder {
@@ -21,6 +30,72 @@ func Delete(table string) *DeleteBuilder {
 	}
 }
 
+// Dialect 指定构建时使用的方言，未指定时使用全局默认方言
+func (builder *DeleteBuilder) Dialect(d Dialect) *DeleteBuilder {
+	builder.dialect = d
+	return builder
+}
+
+// QuoteIdentifier 指定是否引用表名、字段名等标识符，未指定时使用全局设置
+func (builder *DeleteBuilder) QuoteIdentifier(quote bool) *DeleteBuilder {
+	builder.quote = &quote
+	return builder
+}
+
+// Returning delete ... returning columns，返回删除的行，sqlserver构建为output deleted.column
+func (builder *DeleteBuilder) Returning(columns ...string) *DeleteBuilder {
+	builder.returning = append(builder.returning, columns...)
+	return builder
+}
+
+// InnerJoin delete a from a inner join b on ...
+// mysql构建为delete join，postgres构建为delete from a using b where ...
+func (builder *DeleteBuilder) InnerJoin(table string, on WhereInterface) *DeleteBuilder {
+	builder.join = append(builder.join, &Join{
+		link:  "inner",
+		table: table,
+		on:    on,
+	})
+	return builder
+}
+
+// LeftJoin delete a from a left join b on ...，仅mysql支持
+func (builder *DeleteBuilder) LeftJoin(table string, on WhereInterface) *DeleteBuilder {
+	builder.join = append(builder.join, &Join{
+		link:  "left",
+		table: table,
+		on:    on,
+	})
+	return builder
+}
+
+// Targets 关联删除时指定删除哪些表中的行，如 delete a, b from a inner join b on ...
+// 未指定时只删除主表中的行，主表有别名时使用别名，同时删除多个表仅mysql支持
+func (builder *DeleteBuilder) Targets(tables ...string) *DeleteBuilder {
+	builder.targets = append(builder.targets, tables...)
+	return builder
+}
+
+// AllRows 允许在没有where条件时删除所有行，否则安全模式下将返回ErrUnfiltered
+func (builder *DeleteBuilder) AllRows() *DeleteBuilder {
+	builder.allRows = true
+	return builder
+}
+
+// OrderBy delete ... order by column sort，仅mysql支持，column为字段名或Raw表达式，错误的排序规则将被忽略
+func (builder *DeleteBuilder) OrderBy(column interface{}, sort string) *DeleteBuilder {
+	if order := newOrderStat(column, sort); order != nil {
+		builder.order = append(builder.order, order)
+	}
+	return builder
+}
+
+// Limit delete ... limit n，仅mysql支持
+func (builder *DeleteBuilder) Limit(limit int) *DeleteBuilder {
+	builder.limit = limit
+	return builder
+}
+
 func (builder *DeleteBuilder) getWhere() WhereInterface {
 	if builder.where == nil {
 		builder.where = &WhereBuilder{}
@@ -87,53 +162,348 @@ func (builder *DeleteBuilder) OrWhereFunc(f BuilderFunc) *DeleteBuilder {
 	return builder
 }
 
+func (builder *DeleteBuilder) WhereColumn(column1, column2 string) *DeleteBuilder {
+	builder.getWhere().WhereColumn(column1, column2)
+	return builder
+}
+
+func (builder *DeleteBuilder) WhereColumnOperate(column1, operate, column2 string) *DeleteBuilder {
+	builder.getWhere().WhereColumnOperate(column1, operate, column2)
+	return builder
+}
+
+func (builder *DeleteBuilder) OrWhereColumn(column1, column2 string) *DeleteBuilder {
+	builder.getWhere().OrWhereColumn(column1, column2)
+	return builder
+}
+
+func (builder *DeleteBuilder) OrWhereColumnOperate(column1, operate, column2 string) *DeleteBuilder {
+	builder.getWhere().OrWhereColumnOperate(column1, operate, column2)
+	return builder
+}
+
+func (builder *DeleteBuilder) OrWhereIn(column string, value interface{}) *DeleteBuilder {
+	builder.getWhere().OrWhereIn(column, value)
+	return builder
+}
+
+func (builder *DeleteBuilder) OrWhereBetween(column string, min, max interface{}) *DeleteBuilder {
+	builder.getWhere().OrWhereBetween(column, min, max)
+	return builder
+}
+
+func (builder *DeleteBuilder) OrWhereLike(column string, value interface{}) *DeleteBuilder {
+	builder.getWhere().OrWhereLike(column, value)
+	return builder
+}
+
+func (builder *DeleteBuilder) OrWhereNull(column string) *DeleteBuilder {
+	builder.getWhere().OrWhereNull(column)
+	return builder
+}
+
+func (builder *DeleteBuilder) OrWhereNotNull(column string) *DeleteBuilder {
+	builder.getWhere().OrWhereNotNull(column)
+	return builder
+}
+
+// WhereExists where exists (select ...)
+func (builder *DeleteBuilder) WhereExists(f BuilderFunc) *DeleteBuilder {
+	builder.getWhere().WhereExists(f)
+	return builder
+}
+
+func (builder *DeleteBuilder) WhereNotExists(f BuilderFunc) *DeleteBuilder {
+	builder.getWhere().WhereNotExists(f)
+	return builder
+}
+
+func (builder *DeleteBuilder) OrWhereExists(f BuilderFunc) *DeleteBuilder {
+	builder.getWhere().OrWhereExists(f)
+	return builder
+}
+
+func (builder *DeleteBuilder) OrWhereNotExists(f BuilderFunc) *DeleteBuilder {
+	builder.getWhere().OrWhereNotExists(f)
+	return builder
+}
+
+// WhereAny where column > any (select ...)
+func (builder *DeleteBuilder) WhereAny(column, operate string, value interface{}) *DeleteBuilder {
+	builder.getWhere().WhereAny(column, operate, value)
+	return builder
+}
+
+// WhereAll where column > all (select ...)
+func (builder *DeleteBuilder) WhereAll(column, operate string, value interface{}) *DeleteBuilder {
+	builder.getWhere().WhereAll(column, operate, value)
+	return builder
+}
+
+func (builder *DeleteBuilder) OrWhereAny(column, operate string, value interface{}) *DeleteBuilder {
+	builder.getWhere().OrWhereAny(column, operate, value)
+	return builder
+}
+
+func (builder *DeleteBuilder) OrWhereAll(column, operate string, value interface{}) *DeleteBuilder {
+	builder.getWhere().OrWhereAll(column, operate, value)
+	return builder
+}
+
+func (builder *DeleteBuilder) WhereNotIn(column string, value interface{}) *DeleteBuilder {
+	builder.getWhere().WhereNotIn(column, value)
+	return builder
+}
+
+func (builder *DeleteBuilder) WhereNotBetween(column string, min, max interface{}) *DeleteBuilder {
+	builder.getWhere().WhereNotBetween(column, min, max)
+	return builder
+}
+
+func (builder *DeleteBuilder) WhereNotLike(column string, value interface{}) *DeleteBuilder {
+	builder.getWhere().WhereNotLike(column, value)
+	return builder
+}
+
+// WhereNot where not (...)
+func (builder *DeleteBuilder) WhereNot(f BuilderFunc) *DeleteBuilder {
+	builder.getWhere().WhereNot(f)
+	return builder
+}
+
+func (builder *DeleteBuilder) OrWhereNotIn(column string, value interface{}) *DeleteBuilder {
+	builder.getWhere().OrWhereNotIn(column, value)
+	return builder
+}
+
+func (builder *DeleteBuilder) OrWhereNotBetween(column string, min, max interface{}) *DeleteBuilder {
+	builder.getWhere().OrWhereNotBetween(column, min, max)
+	return builder
+}
+
+func (builder *DeleteBuilder) OrWhereNotLike(column string, value interface{}) *DeleteBuilder {
+	builder.getWhere().OrWhereNotLike(column, value)
+	return builder
+}
+
+func (builder *DeleteBuilder) OrWhereNot(f BuilderFunc) *DeleteBuilder {
+	builder.getWhere().OrWhereNot(f)
+	return builder
+}
+
+// String 返回参数插值后的sql，构建过程中有错误时返回空字符串
 func (builder *DeleteBuilder) String() string {
-	sql, data := builder.Build()
-	index := 0
-	newSql := make([]rune, 0, len(data))
-	getData := func(data []interface{}, index int) string {
-		if index > (len(data) - 1) {
-			return ""
-		}
+	c := newBuildContext(builder.dialect, builder.quote)
+	sql, data := builder.build(c)
+	if c.err() != nil {
+		return ""
+	}
+	return interpolate(sql, data)
+}
 
-		datum := data[index]
+// Build 构建sql，构建过程中有错误时返回空sql，错误可以通过BuildE或Err获取
+func (builder *DeleteBuilder) Build() (string, []interface{}) {
+	if err := builder.buildE(); err != nil {
+		return "", nil
+	}
+	return builder.sql, builder.data
+}
 
-		v := reflect.ValueOf(datum)
-		switch v.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return strconv.Itoa(int(v.Int()))
-		case reflect.String:
-			return "\"" + strings.ReplaceAll(strings.ReplaceAll(v.String(), "\\", "\\\\"), "\"", "\\\"") + "\""
-		default:
-			return ""
-		}
+// BuildE 构建sql，构建过程中的错误将被返回
+func (builder *DeleteBuilder) BuildE() (string, []interface{}, error) {
+	if err := builder.buildE(); err != nil {
+		return "", nil, err
 	}
+	return builder.sql, builder.data, nil
+}
+
+// Err 返回构建sql时的错误
+func (builder *DeleteBuilder) Err() error {
+	return builder.buildE()
+}
+
+// buildFor 按执行器的方言构建sql
+func (builder *DeleteBuilder) buildFor(exec Executor) (string, []interface{}, error) {
+	c := newBuildContext(executorDialect(builder.dialect, exec), builder.quote)
+	sql, data := builder.build(c)
+	return rebind(c.dialect, sql), data, c.err()
+}
+
+// ExecContext 构建并执行sql
+func (builder *DeleteBuilder) ExecContext(ctx context.Context, exec Executor) (sql.Result, error) {
+	query, args, err := builder.buildFor(exec)
+	return execContext(ctx, exec, query, args, err)
+}
+
+// QueryContext 构建并执行查询
+func (builder *DeleteBuilder) QueryContext(ctx context.Context, exec Executor) (*sql.Rows, error) {
+	query, args, err := builder.buildFor(exec)
+	return queryContext(ctx, exec, query, args, err)
+}
 
-	for _, sqlRune := range sql {
-		if sqlRune == rune('?') {
-			// 将rune替换成 值
-			repData := getData(data, index)
-			newSql = append(newSql, []rune(repData)...)
-			index++
-		} else {
-			newSql = append(newSql, sqlRune)
+// QueryRowContext 构建并执行查询，返回一行结果
+func (builder *DeleteBuilder) QueryRowContext(ctx context.Context, exec Executor) *Row {
+	query, args, err := builder.buildFor(exec)
+	return queryRowContext(ctx, exec, query, args, err)
+}
+
+// ExecInBatches 按Limit分批删除，重复执行直到没有可删除的行，返回删除的总行数
+// 每批删除在单独的语句中执行，适用于清理大量数据时避免长时间锁表
+func (builder *DeleteBuilder) ExecInBatches(ctx context.Context, exec Executor) (int64, error) {
+	if builder.limit <= 0 {
+		return 0, fmt.Errorf("%w: delete in batches without limit", ErrInvalidValue)
+	}
+	query, args, err := builder.buildFor(exec)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		result, err := exec.ExecContext(ctx, query, args...)
+		if err != nil {
+			return total, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += affected
+		if affected < int64(builder.limit) {
+			return total, nil
 		}
 	}
-	return string(newSql)
 }
 
-func (builder *DeleteBuilder) Build() (string, []interface{}) {
+// ScanStruct 执行并将Returning返回的第一行扫描到结构体中，dst必须为结构体指针
+func (builder *DeleteBuilder) ScanStruct(ctx context.Context, exec Executor, dst interface{}) error {
+	v, err := structDest(dst)
+	if err != nil {
+		return err
+	}
+	rows, err := builder.QueryContext(ctx, exec)
+	if err != nil {
+		return err
+	}
+	return scanOne(rows, v, defaultUnknownColumnPolicy)
+}
+
+// ScanStructs 执行并将Returning返回的所有行扫描到切片中，dst必须为 *[]T 或 *[]*T
+func (builder *DeleteBuilder) ScanStructs(ctx context.Context, exec Executor, dst interface{}) error {
+	v, err := sliceDest(dst)
+	if err != nil {
+		return err
+	}
+	rows, err := builder.QueryContext(ctx, exec)
+	if err != nil {
+		return err
+	}
+	return scanAll(rows, v, defaultUnknownColumnPolicy)
+}
+
+func (builder *DeleteBuilder) buildE() error {
 	if !builder.isBuilt {
-		sql := fmt.Sprintf("delete from %s", builder.table)
-		if builder.where != nil {
-			where, whereData := builder.where.Build()
-			if where != "" {
-				sql = fmt.Sprintf("%s where %s", sql, where)
-				builder.data = append(builder.data, whereData...)
+		c := newBuildContext(builder.dialect, builder.quote)
+		sql, data := builder.build(c)
+		builder.sql = rebind(c.dialect, sql)
+		builder.data = data
+		builder.err = c.err()
+		builder.isBuilt = true
+	}
+	return builder.err
+}
+
+// tableRef 语句中引用表时使用的名称，有别名时为别名，否则为表名
+// 如 users u、users as u 为 u，users 为 users
+func tableRef(table string) string {
+	fields := strings.Fields(table)
+	switch {
+	case len(fields) == 2:
+		return fields[1]
+	case len(fields) == 3 && strings.EqualFold(fields[1], "as"):
+		return fields[2]
+	}
+	return strings.TrimSpace(table)
+}
+
+func (builder *DeleteBuilder) build(c *buildContext) (string, []interface{}) {
+	if builder.table == "" {
+		c.addError(ErrEmptyTable)
+	}
+	data := make([]interface{}, 0)
+	sql := fmt.Sprintf("delete from %s", c.ident(builder.table))
+	using := ""
+	conditions := make([]string, 0)
+	var conditionsData []interface{}
+	if len(builder.join) > 0 || len(builder.targets) > 0 {
+		switch {
+		case c.dialect.Supports(FeatureDeleteJoin):
+			targets := builder.targets
+			if len(targets) == 0 {
+				targets = []string{tableRef(builder.table)}
+			}
+			sql = fmt.Sprintf("delete %s from %s", strings.Join(c.idents(targets), ", "), c.ident(builder.table))
+			for _, j := range builder.join {
+				join, joinData := j.build(c)
+				sql = fmt.Sprintf("%s %s", sql, join)
+				data = append(data, joinData...)
 			}
+		case c.dialect.Supports(FeatureDeleteUsing):
+			// 关联表放在using中，关联条件并入where
+			for _, target := range builder.targets {
+				if target != tableRef(builder.table) {
+					c.addError(unsupported(c.dialect, FeatureDeleteUsing.String()+" from multiple tables"))
+				}
+			}
+			tables := make([]string, len(builder.join))
+			for i, j := range builder.join {
+				if j.link != "inner" {
+					c.addError(unsupported(c.dialect, FeatureDeleteUsing.String()+" with "+j.link+" join"))
+				}
+				tables[i] = c.ident(j.table)
+				on, onData := buildWith(j.on, c)
+				conditions = append(conditions, on)
+				conditionsData = append(conditionsData, onData...)
+			}
+			if len(tables) > 0 {
+				using = "using " + strings.Join(tables, ", ")
+			}
+		default:
+			c.addError(unsupported(c.dialect, FeatureDeleteJoin))
 		}
-		builder.sql = sql
-		builder.isBuilt = true
 	}
-	return builder.sql, builder.data
+
+	output, returning := buildReturning(c, builder.returning, "deleted")
+	if output != "" {
+		sql = fmt.Sprintf("%s %s", sql, output)
+	}
+	if using != "" {
+		sql = fmt.Sprintf("%s %s", sql, using)
+	}
+	filtered := false
+	if builder.where != nil {
+		where, whereData := builder.where.build(c)
+		if where != "" {
+			filtered = true
+			conditions = append(conditions, where)
+			conditionsData = append(conditionsData, whereData...)
+		}
+	}
+	if !filtered && defaultSafeMode && !builder.allRows {
+		c.addError(ErrUnfiltered)
+	}
+	if len(conditions) > 0 {
+		sql = fmt.Sprintf("%s where %s", sql, andConditions(conditions))
+		data = append(data, conditionsData...)
+	}
+	if orderLimit, orderData := buildOrderLimit(c, builder.order, builder.limit, len(builder.join) > 0 || len(builder.targets) > 0); orderLimit != "" {
+		sql = fmt.Sprintf("%s %s", sql, orderLimit)
+		data = append(data, orderData...)
+	}
+	if returning != "" {
+		sql = fmt.Sprintf("%s %s", sql, returning)
+	}
+	return sql, data
 }
@@ -1,14 +1,18 @@
 package sqlbuilder_test
 
 import (
+	"context"
+	"errors"
+	"reflect"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/sureyee/sqlbuilder"
 )
 
 func TestDelete(t *testing.T) {
 	sql := "delete from users"
-	builderSql := sqlbuilder.Delete("users").String()
+	builderSql := sqlbuilder.Delete("users").AllRows().String()
 	if sql != builderSql {
 		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
 	}
@@ -29,3 +33,218 @@ func TestDeleteWhereIn(t *testing.T) {
 		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
 	}
 }
+
+func TestDeleteReturning(t *testing.T) {
+	sql := "delete from users where id = ? returning *"
+	builderSql, _ := sqlbuilder.Delete("users").Where("id", 10).Returning("*").Dialect(sqlbuilder.SQLite).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	sql = "delete from users output deleted.id where id = @p1"
+	builderSql, _ = sqlbuilder.Delete("users").Where("id", 10).Returning("id").Dialect(sqlbuilder.SQLServer).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestDeleteJoin(t *testing.T) {
+	sql := "delete users from users inner join orders on orders.user_id = users.id where orders.status = ?"
+	builderSql, _ := sqlbuilder.Delete("users").
+		InnerJoin("orders", sqlbuilder.WhereColumn("orders.user_id", "users.id")).
+		Where("orders.status", 0).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	sql = "delete `users`, `orders` from `users` left join `orders` on `orders`.`user_id` = `users`.`id` where `users`.`status` = ?"
+	builderSql, _ = sqlbuilder.Delete("users").
+		LeftJoin("orders", sqlbuilder.WhereColumn("orders.user_id", "users.id")).
+		Targets("users", "orders").
+		Where("users.status", 0).
+		QuoteIdentifier(true).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	// 主表有别名时删除目标为别名
+	sql = "delete `u` from `users` `u` inner join `orders` `o` on `o`.`user_id` = `u`.`id` where `o`.`status` = ?"
+	builderSql, _ = sqlbuilder.Delete("users u").
+		InnerJoin("orders o", sqlbuilder.WhereColumn("o.user_id", "u.id")).
+		Where("o.status", 0).
+		QuoteIdentifier(true).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	sql = "delete u from users as u inner join orders o on o.user_id = u.id where o.status = ?"
+	builderSql, _ = sqlbuilder.Delete("users as u").
+		InnerJoin("orders o", sqlbuilder.WhereColumn("o.user_id", "u.id")).
+		Where("o.status", 0).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	// postgres中Targets只能为主表的别名
+	sql = "delete from users u using orders o where (o.user_id = u.id) and (o.status = $1)"
+	builderSql, _ = sqlbuilder.Delete("users u").
+		InnerJoin("orders o", sqlbuilder.WhereColumn("o.user_id", "u.id")).
+		Targets("u").
+		Where("o.status", 0).
+		Dialect(sqlbuilder.PostgreSQL).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestDeleteUsing(t *testing.T) {
+	sql := "delete from users using orders where (orders.user_id = users.id and orders.status = $1) and (users.id > $2)"
+	builderSql, builderData := sqlbuilder.Delete("users").
+		InnerJoin("orders", sqlbuilder.WhereColumn("orders.user_id", "users.id").Where("orders.status", 0)).
+		WhereOperate("users.id", ">", 10).
+		Dialect(sqlbuilder.PostgreSQL).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if !reflect.DeepEqual(builderData, []interface{}{0, 10}) {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+
+	sql = "delete from users using orders where (orders.user_id = users.id) and (status = 0 OR status IS NULL)"
+	builderSql, _ = sqlbuilder.Delete("users").
+		InnerJoin("orders", sqlbuilder.WhereColumn("orders.user_id", "users.id")).
+		WhereFunc(func() sqlbuilder.Builder {
+			return sqlbuilder.Raw("status = 0 OR status IS NULL")
+		}).
+		Dialect(sqlbuilder.PostgreSQL).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	err := sqlbuilder.Delete("users").
+		InnerJoin("orders", sqlbuilder.WhereColumn("orders.user_id", "users.id")).
+		Targets("users", "orders").
+		Dialect(sqlbuilder.PostgreSQL).
+		Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+	}
+
+	err = sqlbuilder.Delete("users").
+		InnerJoin("orders", sqlbuilder.WhereColumn("orders.user_id", "users.id")).
+		Dialect(sqlbuilder.SQLite).
+		Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+	}
+}
+
+func TestDeleteOrderLimit(t *testing.T) {
+	sql := "delete from logs where created_at < ? order by id asc limit 1000"
+	builderSql, _ := sqlbuilder.Delete("logs").WhereOperate("created_at", "<", "2020-01-01").OrderBy("id", "asc").Limit(1000).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	err := sqlbuilder.Delete("logs").Limit(1000).Dialect(sqlbuilder.SQLite).Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+	}
+
+	err = sqlbuilder.Delete("logs").
+		InnerJoin("users", sqlbuilder.WhereColumn("users.id", "logs.user_id")).
+		Limit(1000).
+		Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+	}
+}
+
+func TestDeleteExecInBatches(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	sql := "delete from logs where created_at < ? order by id asc limit 2"
+	mock.ExpectExec(sql).WithArgs("2020-01-01").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(sql).WithArgs("2020-01-01").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(sql).WithArgs("2020-01-01").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	total, err := sqlbuilder.Delete("logs").
+		WhereOperate("created_at", "<", "2020-01-01").
+		OrderBy("id", "asc").
+		Limit(2).
+		ExecInBatches(context.Background(), db)
+	if err != nil {
+		t.Fatalf("ExecInBatches error: %v", err)
+	}
+	if total != 5 {
+		t.Errorf("expected:`%v`, got:`%v`", 5, total)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	_, err = sqlbuilder.Delete("logs").ExecInBatches(context.Background(), db)
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+}
+
+func TestDeleteSafeMode(t *testing.T) {
+	err := sqlbuilder.Delete("users").Err()
+	if !errors.Is(err, sqlbuilder.ErrUnfiltered) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnfiltered, err)
+	}
+
+	// 安全模式下Build、String不返回没有where条件的sql
+	if builderSql, builderData := sqlbuilder.Delete("users").Build(); builderSql != "" || builderData != nil {
+		t.Errorf("expected empty result, got:`%v` %v", builderSql, builderData)
+	}
+	if builderSql := sqlbuilder.Delete("users").String(); builderSql != "" {
+		t.Errorf("expected:`%v`, got:`%v`", "", builderSql)
+	}
+
+	// 空的条件分组同样视为没有where条件
+	err = sqlbuilder.Delete("users").WhereFunc(func() sqlbuilder.Builder {
+		return &sqlbuilder.WhereBuilder{}
+	}).Err()
+	if !errors.Is(err, sqlbuilder.ErrUnfiltered) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnfiltered, err)
+	}
+
+	sql, _, err := sqlbuilder.Delete("users").AllRows().BuildE()
+	if err != nil || sql != "delete from users" {
+		t.Errorf("expected:`%v`, got:`%v` %v", "delete from users", sql, err)
+	}
+
+	sqlbuilder.SetSafeMode(false)
+	defer sqlbuilder.SetSafeMode(true)
+	if err := sqlbuilder.Delete("users").Err(); err != nil {
+		t.Errorf("expected:`%v`, got:`%v`", nil, err)
+	}
+}
+
+func TestDeleteSafeModeExecContext(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	_, err = sqlbuilder.Delete("users").ExecContext(context.Background(), db)
+	if !errors.Is(err, sqlbuilder.ErrUnfiltered) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnfiltered, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,320 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect 数据库方言
+// 不同数据库在占位符、分页等语法上存在差异，由方言负责渲染这些差异
+type Dialect interface {
+	// Name 方言名称
+	Name() string
+	// Placeholder 返回第n个参数的占位符，n从1开始
+	Placeholder(n int) string
+	// Paginate 构建分页语句
+	// top 添加在select之后(如sqlserver的top n)，suffix 添加在语句末尾，
+	// ordered 表示语句中是否包含order by，部分方言的分页依赖排序
+	Paginate(offset, limit int, ordered bool) (top, suffix string, err error)
+	// Lock 构建锁语句
+	// hint 添加在表名之后(如sqlserver的with (updlock))，suffix 添加在语句末尾
+	Lock(locker Locker) (hint, suffix string, err error)
+	// Insert 构建insert语句的关键字，如 insert into、insert or ignore into
+	Insert(mode InsertMode) (string, error)
+	// MaxPlaceholders 单条语句允许的最大参数数量，0表示不限制
+	MaxPlaceholders() int
+	// Supports 是否支持指定特性
+	Supports(feature Feature) bool
+	// Quote 引用单个标识符，如 users => `users`
+	Quote(ident string) string
+}
+
+// Feature 方言特性，不同数据库支持的语法不同
+type Feature int
+
+const (
+	// FeatureReturning insert/update/delete ... returning
+	FeatureReturning Feature = iota
+	// FeatureOnConflict insert ... on conflict
+	FeatureOnConflict
+	// FeatureOnDuplicateKey insert ... on duplicate key update
+	FeatureOnDuplicateKey
+	// FeatureOnConstraint insert ... on conflict on constraint
+	FeatureOnConstraint
+	// FeatureOutput insert/update/delete ... output inserted.*，sqlserver中代替returning
+	FeatureOutput
+	// FeatureUpdateJoin update a inner join b on ... set ...
+	FeatureUpdateJoin
+	// FeatureUpdateFrom update a set ... from b where ...
+	FeatureUpdateFrom
+	// FeatureDeleteJoin delete a from a inner join b on ...
+	FeatureDeleteJoin
+	// FeatureDeleteUsing delete from a using b where ...
+	FeatureDeleteUsing
+	// FeatureWriteLimit update/delete ... order by ... limit n
+	FeatureWriteLimit
+	// FeatureAnyAll column > any (select ...)
+	FeatureAnyAll
+	// FeatureArray column = any (?)，参数为数组
+	FeatureArray
+	// FeatureDefaultValue insert ... values (default)，批量插入结构体时零值的auto、default字段使用
+	FeatureDefaultValue
+)
+
+func (f Feature) String() string {
+	switch f {
+	case FeatureReturning:
+		return "returning"
+	case FeatureOnConflict:
+		return "on conflict"
+	case FeatureOnDuplicateKey:
+		return "on duplicate key update"
+	case FeatureOnConstraint:
+		return "on conflict on constraint"
+	case FeatureOutput:
+		return "output"
+	case FeatureUpdateJoin:
+		return "update join"
+	case FeatureUpdateFrom:
+		return "update from"
+	case FeatureDeleteJoin:
+		return "delete join"
+	case FeatureDeleteUsing:
+		return "delete using"
+	case FeatureWriteLimit:
+		return "update/delete limit"
+	case FeatureAnyAll:
+		return "any/all subquery"
+	case FeatureArray:
+		return "array parameter"
+	case FeatureDefaultValue:
+		return "default in values"
+	}
+	return "feature(" + strconv.Itoa(int(f)) + ")"
+}
+
+var (
+	// MySQL mysql方言，使用?占位符
+	MySQL Dialect = &mysqlDialect{}
+	// PostgreSQL postgres方言，使用$1, $2...占位符
+	PostgreSQL Dialect = &postgresDialect{}
+	// SQLServer sqlserver方言，使用@p1, @p2...占位符
+	SQLServer Dialect = &sqlserverDialect{}
+	// SQLite sqlite方言，使用?占位符，忽略不支持的锁
+	SQLite Dialect = NewSQLiteDialect(false)
+)
+
+var (
+	defaultDialect  = MySQL
+	defaultQuote    = false
+	defaultSafeMode = true
+)
+
+// SetDialect 设置全局默认方言
+// 未单独指定方言的builder都将使用该方言构建
+func SetDialect(d Dialect) {
+	if d == nil {
+		d = MySQL
+	}
+	defaultDialect = d
+}
+
+// DefaultDialect 获取全局默认方言
+func DefaultDialect() Dialect {
+	return defaultDialect
+}
+
+// SetQuoteIdentifier 设置是否默认引用表名、字段名等标识符
+// 开启后 order 会被构建为 `order`(mysql) 或 "order"(postgres)
+func SetQuoteIdentifier(quote bool) {
+	defaultQuote = quote
+}
+
+// SetSafeMode 设置是否开启安全模式，默认开启
+// 安全模式下没有where条件的update、delete将返回ErrUnfiltered，除非调用了AllRows
+func SetSafeMode(safe bool) {
+	defaultSafeMode = safe
+}
+
+// buildContext 构建上下文
+// 嵌套的子查询、where分组、join等共用同一个上下文，保证方言和引用规则一致
+type buildContext struct {
+	dialect Dialect
+	quote   bool
+	errs    Errors
+}
+
+func newBuildContext(d Dialect, quote *bool) *buildContext {
+	c := &buildContext{
+		dialect: d,
+		quote:   defaultQuote,
+	}
+	if c.dialect == nil {
+		c.dialect = defaultDialect
+	}
+	if quote != nil {
+		c.quote = *quote
+	}
+	return c
+}
+
+// addError 记录构建过程中的错误
+func (c *buildContext) addError(err error) {
+	if err != nil {
+		c.errs = append(c.errs, err)
+	}
+}
+
+// err 返回构建过程中的错误，只有一个错误时直接返回该错误
+func (c *buildContext) err() error {
+	switch len(c.errs) {
+	case 0:
+		return nil
+	case 1:
+		return c.errs[0]
+	}
+	return c.errs
+}
+
+// ident 按方言引用标识符，未开启引用时原样返回
+func (c *buildContext) ident(name string) string {
+	if !c.quote {
+		return name
+	}
+	return quoteIdentifier(c.dialect, name)
+}
+
+// strictIdent 用于order by等可能来自用户输入的字段，避免sql注入
+// 只接受字段名或表名.字段名，开启引用时其他内容整体作为一个标识符引用，未开启引用时记录错误
+func (c *buildContext) strictIdent(name string) string {
+	name = strings.TrimSpace(name)
+	if isQualifiedIdentifier(name) {
+		return c.ident(name)
+	}
+	if c.quote {
+		return c.dialect.Quote(name)
+	}
+	c.addError(fmt.Errorf("%w: %q", ErrInvalidIdentifier, name))
+	return name
+}
+
+func (c *buildContext) idents(names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = c.ident(name)
+	}
+	return quoted
+}
+
+// dialectBuilder 可以按构建上下文构建的builder
+// 构建出的sql统一使用?作为占位符，最终由rebind替换为方言的占位符，
+// 这样嵌套的子查询、where分组中的参数才能被正确编号
+type dialectBuilder interface {
+	build(c *buildContext) (string, []interface{})
+}
+
+// buildWith 使用构建上下文构建builder
+func buildWith(b Builder, c *buildContext) (string, []interface{}) {
+	if b == nil {
+		return "", nil
+	}
+	if db, ok := b.(dialectBuilder); ok {
+		return db.build(c)
+	}
+	return b.Build()
+}
+
+// rebind 将sql中的?占位符按顺序替换为方言的占位符
+// 引号内的?不会被替换，sqlserver中[]引用的标识符内的?同样不会被替换
+func rebind(d Dialect, sql string) string {
+	if d.Placeholder(1) == "?" {
+		return sql
+	}
+	var b strings.Builder
+	b.Grow(len(sql) + 8)
+	n := 0
+	var quote rune
+	// postgres的ARRAY[?]、arr[?]中的占位符需要替换
+	brackets := strings.HasPrefix(d.Quote("x"), "[")
+	for _, r := range sql {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '[' && brackets:
+			quote = ']'
+		case r == '?':
+			n++
+			b.WriteString(d.Placeholder(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// quoteIdentifier 引用标识符
+// 支持 column、table.column、alias.*、column as alias 几种形式，
+// 其他形式(函数、运算表达式、已引用的标识符等)视为原生表达式，原样返回
+func quoteIdentifier(d Dialect, name string) string {
+	name = strings.TrimSpace(name)
+	if name == "*" {
+		return name
+	}
+	if i := strings.Index(strings.ToLower(name), " as "); i > 0 {
+		expr, alias := strings.TrimSpace(name[:i]), strings.TrimSpace(name[i+4:])
+		if isIdentifier(alias) {
+			return quoteIdentifier(d, expr) + " as " + d.Quote(alias)
+		}
+		return name
+	}
+	// 表名 别名，如 users u
+	if fields := strings.Fields(name); len(fields) == 2 && isQualifiedIdentifier(fields[0]) && isIdentifier(fields[1]) {
+		return quoteIdentifier(d, fields[0]) + " " + d.Quote(fields[1])
+	}
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if part == "*" && i == len(parts)-1 && i > 0 {
+			continue
+		}
+		if !isIdentifier(part) {
+			return name
+		}
+	}
+	for i, part := range parts {
+		if part != "*" {
+			parts[i] = d.Quote(part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// isQualifiedIdentifier 是否为字段名或以.分隔的表名.字段名
+func isQualifiedIdentifier(s string) bool {
+	for _, part := range strings.Split(s, ".") {
+		if !isIdentifier(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// isIdentifier 是否为普通标识符
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '$'):
+		default:
+			return false
+		}
+	}
+	return true
+}
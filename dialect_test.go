@@ -0,0 +1,334 @@
+package sqlbuilder_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sureyee/sqlbuilder"
+)
+
+func TestPostgresPlaceholder(t *testing.T) {
+	sql := "select * from users where id = $1 and status = $2"
+	builderSql, builderData := sqlbuilder.Select("*").From("users").
+		Where("id", 10).
+		Where("status", 1).
+		Dialect(sqlbuilder.PostgreSQL).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if !reflect.DeepEqual(builderData, []interface{}{10, 1}) {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+}
+
+func TestPostgresNestedPlaceholder(t *testing.T) {
+	sql := "select count(1) from users left join books on books.user_id = users.id and books.status = $1 " +
+		"where gender = $2 and (age < $3 or age > $4) and id in (select user_id from orders where amount > $5) " +
+		"group by age having age > $6 limit 10 offset 20"
+	builderSql, builderData := sqlbuilder.Select("count(1)").From("users").
+		LeftJoin("books", sqlbuilder.WhereColumn("books.user_id", "users.id").Where("books.status", 1)).
+		Where("gender", "F").
+		WhereFunc(func() sqlbuilder.Builder {
+			return sqlbuilder.WhereOperate("age", "<", 10).OrWhereOperate("age", ">", 30)
+		}).
+		WhereIn("id", func() sqlbuilder.Builder {
+			return sqlbuilder.Select("user_id").From("orders").WhereOperate("amount", ">", 100)
+		}).
+		GroupBy("age").
+		Having(func() sqlbuilder.Builder {
+			return sqlbuilder.WhereOperate("age", ">", 18)
+		}).
+		Limit(20, 10).
+		Dialect(sqlbuilder.PostgreSQL).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if !reflect.DeepEqual(builderData, []interface{}{1, "F", 10, 30, 100, 18}) {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+}
+
+func TestPostgresArrayPlaceholder(t *testing.T) {
+	sql := "select * from users where arr = ARRAY[$1, $2] and tags[$3] = $4 and b = $5"
+	builderSql, builderData := sqlbuilder.Select("*").From("users").
+		WhereFunc(func() sqlbuilder.Builder {
+			return sqlbuilder.Raw("arr = ARRAY[?, ?] and tags[?] = ?", 1, 2, 1, "go")
+		}).
+		Where("b", 2).
+		Dialect(sqlbuilder.PostgreSQL).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if !reflect.DeepEqual(builderData, []interface{}{1, 2, 1, "go", 2}) {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+
+	// sqlserver中[]为引用的标识符
+	sql = "select * from users where [a?] = @p1"
+	builderSql, _ = sqlbuilder.Select("*").From("users").WhereFunc(func() sqlbuilder.Builder {
+		return sqlbuilder.Raw("[a?] = ?", 1)
+	}).Dialect(sqlbuilder.SQLServer).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestWhereBuilderDialect(t *testing.T) {
+	sql := "\"status\" = $1 and \"id\" in ($2, $3)"
+	builderSql, builderData := sqlbuilder.Where("status", 1).
+		Dialect(sqlbuilder.PostgreSQL).
+		QuoteIdentifier(true).
+		WhereIn("id", []int{1, 2}).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if !reflect.DeepEqual(builderData, []interface{}{1, 1, 2}) {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+
+	// 作为其他builder的条件时使用外层builder的方言
+	sql = "select * from users where status = ?"
+	builderSql, _ = sqlbuilder.Select("*").From("users").WhereFunc(func() sqlbuilder.Builder {
+		return sqlbuilder.Where("status", 1).Dialect(sqlbuilder.PostgreSQL)
+	}).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestPostgresLimit(t *testing.T) {
+	sql := "select * from users limit 10"
+	builderSql, _ := sqlbuilder.Select("*").From("users").Limit(0, 10).Dialect(sqlbuilder.PostgreSQL).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestPostgresUpdateDelete(t *testing.T) {
+	sql := "update users set age = age + $1 where id = $2"
+	builderSql, _ := sqlbuilder.Update("users").Increment("age", 1).Where("id", 1).Dialect(sqlbuilder.PostgreSQL).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	sql = "delete from users where id in ($1, $2)"
+	builderSql, _ = sqlbuilder.Delete("users").WhereIn("id", []int{1, 2}).Dialect(sqlbuilder.PostgreSQL).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	sql = "insert into users (username, age) values ($1, $2)"
+	builderSql, _ = sqlbuilder.Insert("users").Fields("username", "age").Values("zhangsan", 10).Dialect(sqlbuilder.PostgreSQL).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestSetDialect(t *testing.T) {
+	sqlbuilder.SetDialect(sqlbuilder.PostgreSQL)
+	defer sqlbuilder.SetDialect(sqlbuilder.MySQL)
+
+	sql := "select * from users where id = $1"
+	builderSql, _ := sqlbuilder.Select("*").From("users").Where("id", 1).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	// String 输出不受占位符影响
+	sql = "select * from users where username = \"zhangsan\""
+	builderSql = sqlbuilder.Select("*").From("users").Where("username", "zhangsan").String()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	sql := "select `u`.`id`, `b`.*, count(1), `u`.`name` as `username` from `users` " +
+		"left join `books` on `books`.`user_id` = `u`.`id` where `order` = ? group by `group` order by `u`.`id` desc"
+	builderSql, _ := sqlbuilder.Select("u.id", "b.*", "count(1)", "u.name as username").From("users").
+		LeftJoin("books", sqlbuilder.WhereColumn("books.user_id", "u.id")).
+		Where("order", 1).
+		GroupBy("group").
+		OrderBy("u.id", "desc").
+		QuoteIdentifier(true).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestQuoteIdentifierPostgres(t *testing.T) {
+	sql := "update \"users\" set \"age\" = \"age\" + $1 where \"id\" = $2"
+	builderSql, _ := sqlbuilder.Update("users").Increment("age", 1).Where("id", 1).
+		Dialect(sqlbuilder.PostgreSQL).
+		QuoteIdentifier(true).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	sql = "insert into \"order\" (\"user\", \"group\") values ($1, $2)"
+	builderSql, _ = sqlbuilder.Insert("order").Fields("user", "group").Values(1, 2).
+		Dialect(sqlbuilder.PostgreSQL).
+		QuoteIdentifier(true).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestQuoteOrderByInjection(t *testing.T) {
+	sql := "select * from `users` order by `id; drop table users` asc"
+	builderSql, _ := sqlbuilder.Select("*").From("users").OrderBy("id; drop table users", "asc").QuoteIdentifier(true).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestOrderByInjection(t *testing.T) {
+	// 未开启引用时同样校验排序字段
+	builder := sqlbuilder.Select("*").From("users").OrderBy("id desc, (select 1)", "asc")
+	if err := builder.Err(); !errors.Is(err, sqlbuilder.ErrInvalidIdentifier) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidIdentifier, err)
+	}
+	if builderSql, _ := builder.Build(); builderSql != "" {
+		t.Errorf("expected:`%v`, got:`%v`", "", builderSql)
+	}
+
+	err := sqlbuilder.Delete("logs").Where("status", 0).OrderBy("id; drop table users", "asc").Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidIdentifier) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidIdentifier, err)
+	}
+
+	sql := "select * from users order by u.id desc"
+	builderSql, _ := sqlbuilder.Select("*").From("users").OrderBy("u.id", "desc").Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestRawExpression(t *testing.T) {
+	sql := "select `id`, now(), coalesce(nickname, ?) as name from `users` where `status` = ? order by field(id, ?, ?) asc"
+	builderSql, builderData := sqlbuilder.Select("id").
+		SelectRaw("now()").
+		SelectRaw("coalesce(nickname, ?) as name", "guest").
+		From("users").
+		Where("status", 1).
+		OrderBy(sqlbuilder.Raw("field(id, ?, ?)", 3, 1), "asc").
+		QuoteIdentifier(true).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if !reflect.DeepEqual(builderData, []interface{}{"guest", 1, 3, 1}) {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+}
+
+func TestQuoteTableAlias(t *testing.T) {
+	sql := "select `u`.`id` from `users` `u` inner join `orders` `o` on `o`.`user_id` = `u`.`id`"
+	builderSql, _ := sqlbuilder.Select("u.id").From("users u").
+		InnerJoin("orders o", sqlbuilder.WhereColumn("o.user_id", "u.id")).
+		QuoteIdentifier(true).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestSetQuoteIdentifier(t *testing.T) {
+	sqlbuilder.SetQuoteIdentifier(true)
+	defer sqlbuilder.SetQuoteIdentifier(false)
+
+	sql := "delete from `users` where `id` = ?"
+	builderSql, _ := sqlbuilder.Delete("users").Where("id", 1).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	// 可以单独关闭引用，以便使用原生表达式
+	sql = "select now from users"
+	builderSql, _ = sqlbuilder.Select("now").From("users").QuoteIdentifier(false).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestSQLServerTop(t *testing.T) {
+	sql := "select top 10 * from users with (updlock, rowlock) where id > @p1 and status = @p2"
+	builderSql, _ := sqlbuilder.Select("*").From("users").
+		WhereOperate("id", ">", 10).
+		Where("status", 1).
+		Limit(0, 10).
+		LockForUpdate().
+		Dialect(sqlbuilder.SQLServer).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestSQLServerOffsetFetch(t *testing.T) {
+	sql := "select [id], [name] from [users] where [id] in (@p1, @p2) order by [id] desc offset 20 rows fetch next 10 rows only"
+	builderSql, _ := sqlbuilder.Select("id", "name").From("users").
+		WhereIn("id", []int{1, 2}).
+		OrderBy("id", "desc").
+		Limit(20, 10).
+		Dialect(sqlbuilder.SQLServer).
+		QuoteIdentifier(true).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestSQLServerOffsetWithoutOrder(t *testing.T) {
+	builder := sqlbuilder.Select("*").From("users").Limit(20, 10).Dialect(sqlbuilder.SQLServer)
+	if err := builder.Err(); !errors.Is(err, sqlbuilder.ErrOffsetWithoutOrder) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrOffsetWithoutOrder, err)
+	}
+	if builderSql, _ := builder.Build(); builderSql != "" {
+		t.Errorf("expected:`%v`, got:`%v`", "", builderSql)
+	}
+}
+
+func TestPostgresShareLock(t *testing.T) {
+	sql := "select * from users where id = $1 for share"
+	builderSql, _ := sqlbuilder.Select("*").From("users").Where("id", 1).LockShareMode().Dialect(sqlbuilder.PostgreSQL).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestSQLiteLimit(t *testing.T) {
+	sql := "select * from \"users\" where \"id\" > ? limit 10 offset 20"
+	builderSql, _ := sqlbuilder.Select("*").From("users").WhereOperate("id", ">", 1).Limit(20, 10).
+		Dialect(sqlbuilder.SQLite).QuoteIdentifier(true).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	sql = "select * from users limit -1 offset 20"
+	builderSql, _ = sqlbuilder.Select("*").From("users").Limit(20, 0).Dialect(sqlbuilder.SQLite).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestSQLiteLock(t *testing.T) {
+	sql := "select * from users where id = ?"
+	builderSql, _ := sqlbuilder.Select("*").From("users").Where("id", 1).LockForUpdate().Dialect(sqlbuilder.SQLite).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	err := sqlbuilder.Select("*").From("users").LockForUpdate().Dialect(sqlbuilder.NewSQLiteDialect(true)).Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedLock) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedLock, err)
+	}
+}
@@ -0,0 +1,62 @@
+package sqlbuilder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrEmptyTable 未指定表名
+	ErrEmptyTable = errors.New("sqlbuilder: table is empty")
+	// ErrInvalidOperator 不支持的where操作符
+	ErrInvalidOperator = errors.New("sqlbuilder: invalid operator")
+	// ErrInvalidIdentifier order by等字段不是合法的字段名，表达式请使用Raw
+	ErrInvalidIdentifier = errors.New("sqlbuilder: invalid identifier")
+	// ErrInvalidValue where条件的值类型错误
+	ErrInvalidValue = errors.New("sqlbuilder: invalid value")
+	// ErrEmptyValues insert没有指定值或update没有指定set字段
+	ErrEmptyValues = errors.New("sqlbuilder: values is empty")
+	// ErrFieldsMismatch insert的字段数量与值的数量不一致
+	ErrFieldsMismatch = errors.New("sqlbuilder: fields and values count mismatch")
+	// ErrTooManyPlaceholders 参数数量超出方言的限制
+	ErrTooManyPlaceholders = errors.New("sqlbuilder: too many placeholders")
+	// ErrUnknownColumn 扫描结果时结构体中不存在该字段
+	ErrUnknownColumn = errors.New("sqlbuilder: unknown column")
+	// ErrInvalidDestination 扫描结果的目标类型错误
+	ErrInvalidDestination = errors.New("sqlbuilder: invalid scan destination")
+	// ErrUnsupportedLock 方言不支持该锁
+	ErrUnsupportedLock = errors.New("sqlbuilder: lock is not supported by dialect")
+	// ErrUnsupportedFeature 方言不支持该特性
+	ErrUnsupportedFeature = errors.New("sqlbuilder: feature is not supported by dialect")
+	// ErrOffsetWithoutOrder sqlserver使用offset分页时必须指定order by
+	ErrOffsetWithoutOrder = errors.New("sqlbuilder: sqlserver offset pagination requires order by")
+	// ErrUnfiltered 安全模式下update、delete没有where条件，需要更新或删除所有行时请使用AllRows
+	ErrUnfiltered = errors.New("sqlbuilder: update or delete without where")
+)
+
+// Errors 构建过程中收集到的多个错误
+type Errors []error
+
+func (errs Errors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Is 支持errors.Is判断是否包含某个错误
+func (errs Errors) Is(target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// unsupported 构建方言不支持特性的错误
+func unsupported(d Dialect, feature interface{}) error {
+	return fmt.Errorf("%w: %s %v", ErrUnsupportedFeature, d.Name(), feature)
+}
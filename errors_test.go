@@ -0,0 +1,94 @@
+package sqlbuilder_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sureyee/sqlbuilder"
+)
+
+func TestWhereInvalidValue(t *testing.T) {
+	builderSql, builderData, err := sqlbuilder.Select("*").From("users").WhereIn("id", 1).BuildE()
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+	if builderSql != "" || builderData != nil {
+		t.Errorf("expected empty result, got:`%v` %v", builderSql, builderData)
+	}
+
+	err = sqlbuilder.Select("*").From("users").WhereIn("id", []interface{}{1, struct{}{}}).Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+
+	err = sqlbuilder.Update("users").Set("age", 1).WhereIn("id", []int{}).Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+
+	err = sqlbuilder.WhereOperate("created_at", "between", "2020-01").Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+}
+
+func TestWhereInvalidOperator(t *testing.T) {
+	err := sqlbuilder.Delete("users").WhereOperate("id", "= 1 or 1 =", 1).Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidOperator) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidOperator, err)
+	}
+}
+
+func TestNestedError(t *testing.T) {
+	err := sqlbuilder.Select("*").From("users").WhereIn("id", func() sqlbuilder.Builder {
+		return sqlbuilder.Select("user_id").WhereIn("book_id", "1,2")
+	}).Err()
+	if !errors.Is(err, sqlbuilder.ErrEmptyTable) || !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v` and `%v`, got:`%v`", sqlbuilder.ErrEmptyTable, sqlbuilder.ErrInvalidValue, err)
+	}
+}
+
+func TestBuildError(t *testing.T) {
+	err := sqlbuilder.Insert("users").Fields("username", "age").Values("zhangsan").Err()
+	if !errors.Is(err, sqlbuilder.ErrFieldsMismatch) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrFieldsMismatch, err)
+	}
+
+	err = sqlbuilder.Insert("").Values("zhangsan").Err()
+	if !errors.Is(err, sqlbuilder.ErrEmptyTable) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrEmptyTable, err)
+	}
+
+	err = sqlbuilder.Update("users").Where("id", 1).Err()
+	if !errors.Is(err, sqlbuilder.ErrEmptyValues) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrEmptyValues, err)
+	}
+
+	err = sqlbuilder.Insert("users").Values(1).Returning("id").Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+	}
+
+	if err := sqlbuilder.Select("*").From("users").Where("id", 1).Err(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestBuildEmptyOnError(t *testing.T) {
+	builders := []interface {
+		Build() (string, []interface{})
+	}{
+		sqlbuilder.Delete("users").WhereIn("id", "bad"),
+		sqlbuilder.Update("users").Set("a", 1).WhereOperate("id", "=;drop", 1),
+		sqlbuilder.Update("users").Set("a", 1).Where("status", 1).WhereOperate("age", "between", 18),
+		sqlbuilder.Select("*").From("users").Limit(5, 10).Dialect(sqlbuilder.SQLServer),
+		sqlbuilder.Insert("users").Fields("username", "age").Values("zhangsan"),
+		sqlbuilder.Where("a", 1).WhereIn("b", []int{}),
+		sqlbuilder.Where("a", 1).WhereOperate("b", "=;drop", 2),
+	}
+	for _, builder := range builders {
+		if builderSql, builderData := builder.Build(); builderSql != "" || builderData != nil {
+			t.Errorf("expected empty result, got:`%v` %v", builderSql, builderData)
+		}
+	}
+}
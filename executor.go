@@ -0,0 +1,151 @@
+package sqlbuilder
+
+import (
+	"context"
+	"database/sql"
+	"path"
+	"reflect"
+	"strings"
+)
+
+// Executor sql执行器，*sql.DB、*sql.Tx、*sql.Conn均实现了该接口
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// dialectExecutor 指定了方言的执行器
+type dialectExecutor struct {
+	Executor
+	dialect Dialect
+}
+
+// WithDialect 为执行器指定方言
+// 通过该执行器执行的builder，未单独指定方言时使用该方言构建
+func WithDialect(exec Executor, d Dialect) Executor {
+	return &dialectExecutor{
+		Executor: exec,
+		dialect:  d,
+	}
+}
+
+func (exec *dialectExecutor) Dialect() Dialect {
+	return exec.dialect
+}
+
+// executorDialect 获取执行时使用的方言
+// 优先使用builder指定的方言，其次是执行器指定的方言，*sql.DB将根据驱动推断方言
+func executorDialect(d Dialect, exec Executor) Dialect {
+	if d != nil {
+		return d
+	}
+	if e, ok := exec.(interface{ Dialect() Dialect }); ok {
+		return e.Dialect()
+	}
+	if db, ok := exec.(*sql.DB); ok {
+		return driverDialect(db)
+	}
+	return nil
+}
+
+// driverDialect 根据驱动的包名推断方言，无法推断时返回nil
+func driverDialect(db *sql.DB) Dialect {
+	t := reflect.TypeOf(db.Driver())
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	pkg := strings.ToLower(t.PkgPath())
+	switch {
+	case strings.Contains(pkg, "mysql"):
+		return MySQL
+	case path.Base(pkg) == "pq", strings.Contains(pkg, "pgx"), strings.Contains(pkg, "postgres"):
+		return PostgreSQL
+	case strings.Contains(pkg, "sqlite"):
+		return SQLite
+	case strings.Contains(pkg, "mssql"), strings.Contains(pkg, "sqlserver"):
+		return SQLServer
+	}
+	return nil
+}
+
+// Row QueryRowContext的结果，构建sql出错时Scan将返回构建错误
+type Row struct {
+	row *sql.Row
+	err error
+}
+
+func (r *Row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Scan(dest...)
+}
+
+func (r *Row) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Err()
+}
+
+func execContext(ctx context.Context, exec Executor, query string, args []interface{}, err error) (sql.Result, error) {
+	if err != nil {
+		return nil, err
+	}
+	return exec.ExecContext(ctx, query, args...)
+}
+
+// execStatements 依次执行多条语句
+func execStatements(ctx context.Context, exec Executor, statements []*Statement) (sql.Result, error) {
+	results := make(batchResult, 0, len(statements))
+	for _, statement := range statements {
+		result, err := exec.ExecContext(ctx, statement.SQL, statement.Args...)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	if len(results) == 1 {
+		return results[0], nil
+	}
+	return results, nil
+}
+
+// batchResult 多条语句的执行结果
+type batchResult []sql.Result
+
+// LastInsertId 返回第一条语句的LastInsertId，mysql中即为批量插入的第一个自增id
+func (results batchResult) LastInsertId() (int64, error) {
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].LastInsertId()
+}
+
+// RowsAffected 返回所有语句影响的行数之和
+func (results batchResult) RowsAffected() (int64, error) {
+	var total int64
+	for _, result := range results {
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		total += affected
+	}
+	return total, nil
+}
+
+func queryContext(ctx context.Context, exec Executor, query string, args []interface{}, err error) (*sql.Rows, error) {
+	if err != nil {
+		return nil, err
+	}
+	return exec.QueryContext(ctx, query, args...)
+}
+
+func queryRowContext(ctx context.Context, exec Executor, query string, args []interface{}, err error) *Row {
+	if err != nil {
+		return &Row{err: err}
+	}
+	return &Row{row: exec.QueryRowContext(ctx, query, args...)}
+}
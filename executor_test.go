@@ -0,0 +1,146 @@
+package sqlbuilder_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sureyee/sqlbuilder"
+)
+
+func TestSelectQueryContext(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"id", "username"}).
+		AddRow(1, "zhangsan").
+		AddRow(2, "lisi")
+	mock.ExpectQuery("select id, username from users where status = ?").WithArgs(1).WillReturnRows(rows)
+
+	result, err := sqlbuilder.Select("id", "username").From("users").Where("status", 1).QueryContext(context.Background(), db)
+	if err != nil {
+		t.Fatalf("QueryContext error: %v", err)
+	}
+	defer result.Close()
+	count := 0
+	for result.Next() {
+		count++
+	}
+	if count != 2 {
+		t.Errorf("expected 2 rows, got %d", count)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestQueryRowContextWithDialect(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"id", "username"}).AddRow(1, "zhangsan")
+	mock.ExpectQuery("select id, username from users where id = $1 limit 1").WithArgs(1).WillReturnRows(rows)
+
+	exec := sqlbuilder.WithDialect(db, sqlbuilder.PostgreSQL)
+	var data user
+	err = sqlbuilder.Select("id", "username").From("users").Where("id", 1).Limit(0, 1).
+		QueryRowContext(context.Background(), exec).
+		Scan(&data.id, &data.username)
+	if err != nil {
+		t.Errorf("row.Scan error: %v", err)
+	}
+	if data.username != "zhangsan" {
+		t.Errorf("expected:`zhangsan`, got:`%v`", data.username)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestExecContextTx(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	mock.ExpectBegin()
+	mock.ExpectExec("update users set age = ? where id = ?").WithArgs(10, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("delete from books where user_id = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx error: %v", err)
+	}
+	if _, err := sqlbuilder.Update("users").Set("age", 10).Where("id", 1).ExecContext(ctx, tx); err != nil {
+		t.Errorf("ExecContext error: %v", err)
+	}
+	result, err := sqlbuilder.Delete("books").Where("user_id", 1).ExecContext(ctx, tx)
+	if err != nil {
+		t.Errorf("ExecContext error: %v", err)
+	} else if affected, _ := result.RowsAffected(); affected != 3 {
+		t.Errorf("expected 3 rows affected, got %d", affected)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Errorf("Commit error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestExecContextConn(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	mock.ExpectExec("insert into users (username, age) values (?, ?)").WithArgs("zhangsan", 10).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn error: %v", err)
+	}
+	defer conn.Close()
+	if _, err := sqlbuilder.Insert("users").Fields("username", "age").Values("zhangsan", 10).ExecContext(ctx, conn); err != nil {
+		t.Errorf("ExecContext error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestExecContextBuildError(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	_, err = sqlbuilder.Insert("users").Fields("username", "age").Values("zhangsan").ExecContext(ctx, db)
+	if !errors.Is(err, sqlbuilder.ErrFieldsMismatch) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrFieldsMismatch, err)
+	}
+
+	var id int
+	err = sqlbuilder.Select("id").From("users").WhereIn("id", 1).QueryRowContext(ctx, db).Scan(&id)
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
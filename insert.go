@@ -1,18 +1,93 @@
 package sqlbuilder
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"reflect"
-	"strconv"
 	"strings"
 )
 
+// InsertMode 插入冲突时的处理方式
+type InsertMode int
+
+const (
+	// InsertDefault 普通插入
+	InsertDefault InsertMode = iota
+	// InsertIgnore 忽略冲突的行，如 insert ignore into
+	InsertIgnore
+	// InsertReplace 替换冲突的行，如 replace into
+	InsertReplace
+)
+
+func (mode InsertMode) String() string {
+	switch mode {
+	case InsertIgnore:
+		return "insert ignore"
+	case InsertReplace:
+		return "replace"
+	}
+	return "insert"
+}
+
 type InsertBuilder struct {
-	isBuilt bool
-	sql     string
-	table   string
-	field   []string
-	data    []interface{}
+	isBuilt   bool
+	sql       string
+	table     string
+	mode      InsertMode
+	field     []string
+	conflict  *onConflict
+	duplicate []*setStat
+	rowAlias  string
+	returning []string
+	rows      [][]interface{}
+	query     *SelectBuilder
+	dialect   Dialect
+	quote     *bool
+	err       error
+	data      []interface{}
+}
+
+// onConflict insert ... on conflict (columns) do update set ... where ...
+type onConflict struct {
+	columns    []string
+	constraint string
+	doNothing  bool
+	sets       []*setStat
+	where      WhereInterface
+}
+
+// insertedExpr 引用待插入行中的字段
+type insertedExpr struct {
+	column string
+}
+
+// Inserted 引用待插入行中的字段，用于on duplicate key update和on conflict do update
+// mysql构建为 values(column) 或 alias.column，postgres/sqlite构建为 excluded.column
+func Inserted(column string) Builder {
+	return &insertedExpr{column: column}
+}
+
+// Excluded 引用待插入行中的字段，构建为 excluded.column，同Inserted
+func Excluded(column string) Builder {
+	return Inserted(column)
+}
+
+func (expr *insertedExpr) Build() (string, []interface{}) {
+	return "values(" + expr.column + ")", nil
+}
+
+func (expr *insertedExpr) ref(c *buildContext, rowAlias string) string {
+	if rowAlias == "" {
+		return "values(" + c.ident(expr.column) + ")"
+	}
+	return c.ident(rowAlias + "." + expr.column)
+}
+
+// setStat set column = value
+type setStat struct {
+	column string
+	value  interface{}
 }
 
 func Insert(table string) *InsertBuilder {
@@ -21,6 +96,14 @@ func Insert(table string) *InsertBuilder {
 	}
 }
 
+// Replace replace into，冲突时替换已存在的行
+func Replace(table string) *InsertBuilder {
+	return &InsertBuilder{
+		table: table,
+		mode:  InsertReplace,
+	}
+}
+
 func (builder *InsertBuilder) Fields(fields ...string) *InsertBuilder {
 	builder.field = append(builder.field, fields...)
 	return builder
@@ -31,64 +114,493 @@ func (builder *InsertBuilder) Values(values ...interface{}) *InsertBuilder {
 	return builder
 }
 
+// AddRow 添加一行待插入的值，用于批量插入
+func (builder *InsertBuilder) AddRow(values ...interface{}) *InsertBuilder {
+	builder.rows = append(builder.rows, values)
+	return builder
+}
+
+// Rows 添加多行待插入的值，用于批量插入
+// 每一行的值数量必须与Fields一致
+func (builder *InsertBuilder) Rows(rows ...[]interface{}) *InsertBuilder {
+	builder.rows = append(builder.rows, rows...)
+	return builder
+}
+
 func (builder *InsertBuilder) Map(mapData map[string]interface{}) *InsertBuilder {
-	for column, value := range mapData {
+	for _, column := range sortedKeys(mapData) {
 		builder.field = append(builder.field, column)
-		builder.data = append(builder.data, value)
+		builder.data = append(builder.data, mapData[column])
+	}
+
+	return builder
+}
+
+// Ignore insert ignore into，忽略冲突的行，sqlite构建为insert or ignore，postgres构建为on conflict do nothing
+func (builder *InsertBuilder) Ignore() *InsertBuilder {
+	builder.mode = InsertIgnore
+	return builder
+}
+
+// OnConflict insert ... on conflict (columns)，需要搭配DoNothing或DoUpdateSet使用，DoUpdateSet时必须指定columns或改用OnConflictOnConstraint
+func (builder *InsertBuilder) OnConflict(columns ...string) *InsertBuilder {
+	builder.getConflict().columns = columns
+	return builder
+}
+
+// OnConflictOnConstraint insert ... on conflict on constraint name，仅postgres支持
+func (builder *InsertBuilder) OnConflictOnConstraint(name string) *InsertBuilder {
+	builder.getConflict().constraint = name
+	return builder
+}
+
+// DoNothing on conflict ... do nothing
+func (builder *InsertBuilder) DoNothing() *InsertBuilder {
+	builder.getConflict().doNothing = true
+	return builder
+}
+
+// DoUpdateSet on conflict ... do update set column = value
+// value可以是普通的值、Raw表达式，或者通过Excluded引用待插入行的值
+func (builder *InsertBuilder) DoUpdateSet(column string, value interface{}) *InsertBuilder {
+	conflict := builder.getConflict()
+	conflict.sets = append(conflict.sets, &setStat{
+		column: column,
+		value:  value,
+	})
+	return builder
+}
+
+// DoUpdateWhere on conflict ... do update set ... where ...
+func (builder *InsertBuilder) DoUpdateWhere(where WhereInterface) *InsertBuilder {
+	builder.getConflict().where = where
+	return builder
+}
+
+func (builder *InsertBuilder) getConflict() *onConflict {
+	if builder.conflict == nil {
+		builder.conflict = &onConflict{}
+	}
+	return builder.conflict
+}
+
+// OnDuplicateKeyUpdate insert ... on duplicate key update column = value
+// value可以是普通的值、Raw表达式，或者通过Inserted引用待插入行的值
+func (builder *InsertBuilder) OnDuplicateKeyUpdate(column string, value interface{}) *InsertBuilder {
+	builder.duplicate = append(builder.duplicate, &setStat{
+		column: column,
+		value:  value,
+	})
+	return builder
+}
+
+// RowAlias 为待插入的行指定别名，insert ... values (...) as alias
+// 指定后Inserted将被构建为 alias.column 的形式(mysql 8.0.19+)，否则为 values(column)
+// 仅支持on duplicate key update的方言可用，且不能与FromSelect同时使用
+func (builder *InsertBuilder) RowAlias(alias string) *InsertBuilder {
+	builder.rowAlias = alias
+	return builder
+}
+
+// Struct 通过db标签插入结构体，v为结构体或结构体指针，字段按声明顺序插入
+// 标签选项：omitempty 零值时不插入；auto 自增字段，零值时由数据库生成；
+// default 零值时使用数据库的默认值；readonly 只读字段，不插入
+func (builder *InsertBuilder) Struct(v interface{}) *InsertBuilder {
+	rv := indirectValue(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		builder.err = fmt.Errorf("%w: insert expects a struct, got %T", ErrInvalidValue, v)
+		return builder
 	}
+	builder.field, builder.rows = structRows([]reflect.Value{rv})
+	return builder
+}
 
+// Structs 通过db标签批量插入结构体，slice为结构体或结构体指针的切片，标签选项同Struct
+// 批量插入时，omitempty、auto、default字段在所有行均为零值时才不插入，
+// 否则auto、default字段的零值构建为default，omitempty字段的零值原样插入；
+// sqlite不支持在values中使用default，此时将返回ErrUnsupportedFeature
+func (builder *InsertBuilder) Structs(slice interface{}) *InsertBuilder {
+	rv := indirectValue(reflect.ValueOf(slice))
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		builder.err = fmt.Errorf("%w: insert expects a slice of struct, got %T", ErrInvalidValue, slice)
+		return builder
+	}
+	values := make([]reflect.Value, rv.Len())
+	for i := range values {
+		values[i] = indirectValue(rv.Index(i))
+		if values[i].Kind() != reflect.Struct || values[i].Type() != values[0].Type() {
+			builder.err = fmt.Errorf("%w: insert expects a slice of struct, got %T", ErrInvalidValue, slice)
+			return builder
+		}
+	}
+	if len(values) > 0 {
+		builder.field, builder.rows = structRows(values)
+	}
 	return builder
 }
 
+// FromSelect insert into table (fields) select ...，插入查询的结果，不能与Values等同时使用
+func (builder *InsertBuilder) FromSelect(query *SelectBuilder) *InsertBuilder {
+	builder.query = query
+	return builder
+}
+
+// Returning insert ... returning columns，返回插入的行，sqlserver构建为output inserted.column
+func (builder *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	builder.returning = append(builder.returning, columns...)
+	return builder
+}
+
+// Dialect 指定构建时使用的方言，未指定时使用全局默认方言
+func (builder *InsertBuilder) Dialect(d Dialect) *InsertBuilder {
+	builder.dialect = d
+	return builder
+}
+
+// QuoteIdentifier 指定是否引用表名、字段名等标识符，未指定时使用全局设置
+func (builder *InsertBuilder) QuoteIdentifier(quote bool) *InsertBuilder {
+	builder.quote = &quote
+	return builder
+}
+
+// Build 构建sql，构建过程中有错误时返回空sql，错误可以通过BuildE或Err获取
 func (builder *InsertBuilder) Build() (string, []interface{}) {
-	sql := fmt.Sprintf("insert into %s", builder.table)
+	sql, data, err := builder.buildE()
+	if err != nil {
+		return "", nil
+	}
+	return sql, data
+}
 
-	if len(builder.field) > 0 {
-		sql = fmt.Sprintf("%s (%s)", sql, strings.Join(builder.field, ", "))
+// BuildE 构建sql，构建过程中的错误将被返回
+func (builder *InsertBuilder) BuildE() (string, []interface{}, error) {
+	sql, data, err := builder.buildE()
+	if err != nil {
+		return "", nil, err
 	}
+	return sql, data, nil
+}
 
-	replace := make([]string, len(builder.data))
-	for i := 0; i < len(builder.data); i++ {
-		replace[i] = "?"
+// Err 返回构建sql时的错误
+func (builder *InsertBuilder) Err() error {
+	_, _, err := builder.buildE()
+	return err
+}
+
+// buildFor 按执行器的方言构建sql
+func (builder *InsertBuilder) buildFor(exec Executor) (string, []interface{}, error) {
+	c := newBuildContext(executorDialect(builder.dialect, exec), builder.quote)
+	sql, data := builder.build(c)
+	return rebind(c.dialect, sql), data, c.err()
+}
+
+// Statements 构建插入语句，超出方言的占位符数量限制时将按行拆分为多条语句
+func (builder *InsertBuilder) Statements() ([]*Statement, error) {
+	c := newBuildContext(builder.dialect, builder.quote)
+	statements := builder.statements(c)
+	if err := c.err(); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+// ExecContext 构建并执行sql
+// 超出方言的占位符数量限制时将拆分为多条语句依次执行，需要保证原子性时请使用*sql.Tx执行
+func (builder *InsertBuilder) ExecContext(ctx context.Context, exec Executor) (sql.Result, error) {
+	c := newBuildContext(executorDialect(builder.dialect, exec), builder.quote)
+	statements := builder.statements(c)
+	if err := c.err(); err != nil {
+		return nil, err
+	}
+	return execStatements(ctx, exec, statements)
+}
+
+// QueryContext 构建并执行查询
+func (builder *InsertBuilder) QueryContext(ctx context.Context, exec Executor) (*sql.Rows, error) {
+	query, args, err := builder.buildFor(exec)
+	return queryContext(ctx, exec, query, args, err)
+}
+
+// QueryRowContext 构建并执行查询，返回一行结果
+func (builder *InsertBuilder) QueryRowContext(ctx context.Context, exec Executor) *Row {
+	query, args, err := builder.buildFor(exec)
+	return queryRowContext(ctx, exec, query, args, err)
+}
+
+// ScanStruct 执行并将Returning返回的第一行扫描到结构体中，dst必须为结构体指针
+func (builder *InsertBuilder) ScanStruct(ctx context.Context, exec Executor, dst interface{}) error {
+	v, err := structDest(dst)
+	if err != nil {
+		return err
+	}
+	rows, err := builder.QueryContext(ctx, exec)
+	if err != nil {
+		return err
+	}
+	return scanOne(rows, v, defaultUnknownColumnPolicy)
+}
+
+// ScanStructs 执行并将Returning返回的所有行扫描到切片中，dst必须为 *[]T 或 *[]*T
+func (builder *InsertBuilder) ScanStructs(ctx context.Context, exec Executor, dst interface{}) error {
+	v, err := sliceDest(dst)
+	if err != nil {
+		return err
 	}
+	rows, err := builder.QueryContext(ctx, exec)
+	if err != nil {
+		return err
+	}
+	return scanAll(rows, v, defaultUnknownColumnPolicy)
+}
 
-	sql = fmt.Sprintf("%s values (%s)", sql, strings.Join(replace, ", "))
-	builder.sql = sql
+func (builder *InsertBuilder) buildE() (string, []interface{}, error) {
+	c := newBuildContext(builder.dialect, builder.quote)
+	sql, data := builder.build(c)
+	builder.sql = rebind(c.dialect, sql)
 	builder.isBuilt = true
-	return builder.sql, builder.data
+	return builder.sql, data, c.err()
 }
 
-func (builder InsertBuilder) String() string {
-	sql, data := builder.Build()
-	index := 0
-	newSql := make([]rune, 0, len(data))
-	getData := func(data []interface{}, index int) string {
-		if index > (len(data) - 1) {
-			return ""
+func (builder *InsertBuilder) build(c *buildContext) (string, []interface{}) {
+	rows := builder.values()
+	builder.validate(c, rows)
+	sql, data := builder.buildRows(c, rows)
+	if max := c.dialect.MaxPlaceholders(); max > 0 && len(data) > max {
+		c.addError(fmt.Errorf("%w: %d placeholders, %s allows %d", ErrTooManyPlaceholders, len(data), c.dialect.Name(), max))
+	}
+	return sql, data
+}
+
+// statements 构建插入语句，超出方言的占位符数量限制时按行拆分为多条语句
+func (builder *InsertBuilder) statements(c *buildContext) []*Statement {
+	rows := builder.values()
+	builder.validate(c, rows)
+	if builder.query != nil {
+		sql, data := builder.buildRows(c, nil)
+		return []*Statement{{SQL: rebind(c.dialect, sql), Args: data}}
+	}
+	size := len(rows)
+	if max := c.dialect.MaxPlaceholders(); max > 0 && size > 0 {
+		// on conflict等子句中的参数每条语句都会重复，按参数最多的行计算每条语句的行数
+		scratch := newBuildContext(c.dialect, &c.quote)
+		_, data := builder.buildRows(scratch, rows[:1])
+		_, first := buildRow(scratch, rows[0])
+		extra := len(data) - len(first)
+		perRow := 1
+		for _, row := range rows {
+			if _, rowData := buildRow(scratch, row); len(rowData) > perRow {
+				perRow = len(rowData)
+			}
+		}
+		size = (max - extra) / perRow
+		if size < 1 {
+			size = 1
+		}
+	}
+	statements := make([]*Statement, 0, len(rows)/size+1)
+	for start := 0; start < len(rows); start += size {
+		end := start + size
+		if end > len(rows) {
+			end = len(rows)
+		}
+		sql, data := builder.buildRows(c, rows[start:end])
+		statements = append(statements, &Statement{
+			SQL:  rebind(c.dialect, sql),
+			Args: data,
+		})
+	}
+	return statements
+}
+
+// values 所有待插入的行，Values、Map指定的值作为第一行
+func (builder *InsertBuilder) values() [][]interface{} {
+	rows := make([][]interface{}, 0, len(builder.rows)+1)
+	if len(builder.data) > 0 {
+		rows = append(rows, builder.data)
+	}
+	return append(rows, builder.rows...)
+}
+
+func (builder *InsertBuilder) validate(c *buildContext, rows [][]interface{}) {
+	if builder.table == "" {
+		c.addError(ErrEmptyTable)
+	}
+	if builder.err != nil {
+		c.addError(builder.err)
+		return
+	}
+	if builder.query != nil {
+		if len(rows) > 0 {
+			c.addError(fmt.Errorf("%w: insert with both values and select", ErrInvalidValue))
+		}
+		return
+	}
+	if len(rows) == 0 {
+		c.addError(fmt.Errorf("%w: insert without values", ErrEmptyValues))
+		return
+	}
+	columns := len(builder.field)
+	if columns == 0 {
+		columns = len(rows[0])
+	}
+	for i, row := range rows {
+		if len(row) != columns {
+			c.addError(fmt.Errorf("%w: row %d has %d values, expected %d", ErrFieldsMismatch, i, len(row), columns))
+		}
+	}
+}
+
+func (builder *InsertBuilder) buildRows(c *buildContext, rows [][]interface{}) (string, []interface{}) {
+	conflict := builder.conflict
+	verb, err := c.dialect.Insert(builder.mode)
+	if err != nil && builder.mode == InsertIgnore && conflict == nil && c.dialect.Supports(FeatureOnConflict) {
+		// 方言没有insert ignore时(如postgres)，使用on conflict do nothing代替
+		verb, err = c.dialect.Insert(InsertDefault)
+		conflict = &onConflict{doNothing: true}
+	}
+	c.addError(err)
+	sql := fmt.Sprintf("%s %s", verb, c.ident(builder.table))
+
+	if len(builder.field) > 0 {
+		sql = fmt.Sprintf("%s (%s)", sql, strings.Join(c.idents(builder.field), ", "))
+	}
+
+	output, returning := buildReturning(c, builder.returning, "inserted")
+	if output != "" {
+		sql = fmt.Sprintf("%s %s", sql, output)
+	}
+
+	data := make([]interface{}, 0)
+	if builder.query != nil {
+		query, queryData := builder.query.build(c)
+		sql = fmt.Sprintf("%s %s", sql, query)
+		data = append(data, queryData...)
+	} else {
+		values := make([]string, len(rows))
+		for i, row := range rows {
+			value, rowData := buildRow(c, row)
+			values[i] = value
+			data = append(data, rowData...)
+		}
+		sql = fmt.Sprintf("%s values %s", sql, strings.Join(values, ", "))
+	}
+	if builder.rowAlias != "" {
+		if !c.dialect.Supports(FeatureOnDuplicateKey) {
+			c.addError(unsupported(c.dialect, FeatureOnDuplicateKey))
+		}
+		if builder.query != nil {
+			c.addError(fmt.Errorf("%w: row alias with insert select", ErrInvalidValue))
+		}
+		sql = fmt.Sprintf("%s as %s", sql, c.ident(builder.rowAlias))
+	}
+
+	if len(builder.duplicate) > 0 {
+		if !c.dialect.Supports(FeatureOnDuplicateKey) {
+			c.addError(unsupported(c.dialect, FeatureOnDuplicateKey))
+		}
+		sets, setsData := buildSets(c, builder.duplicate, builder.rowAlias)
+		sql = fmt.Sprintf("%s on duplicate key update %s", sql, sets)
+		data = append(data, setsData...)
+	}
+
+	if conflict != nil {
+		if !c.dialect.Supports(FeatureOnConflict) {
+			c.addError(unsupported(c.dialect, FeatureOnConflict))
 		}
+		conflictSql, conflictData := conflict.build(c)
+		sql = fmt.Sprintf("%s %s", sql, conflictSql)
+		data = append(data, conflictData...)
+	}
 
-		datum := data[index]
+	if returning != "" {
+		sql = fmt.Sprintf("%s %s", sql, returning)
+	}
+	return sql, data
+}
 
-		v := reflect.ValueOf(datum)
-		switch v.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return strconv.Itoa(int(v.Int()))
-		case reflect.String:
-			return "\"" + strings.ReplaceAll(strings.ReplaceAll(v.String(), "\\", "\\\\"), "\"", "\\\"") + "\""
-		default:
-			return ""
+// buildRow 构建一行待插入的值，值为Builder时作为表达式构建
+func buildRow(c *buildContext, row []interface{}) (string, []interface{}) {
+	data := make([]interface{}, 0, len(row))
+	replace := make([]string, len(row))
+	for i, value := range row {
+		if value == defaultValue && !c.dialect.Supports(FeatureDefaultValue) {
+			c.addError(unsupported(c.dialect, FeatureDefaultValue))
+		}
+		if expr, ok := value.(Builder); ok {
+			exprSql, exprData := buildWith(expr, c)
+			replace[i] = exprSql
+			data = append(data, exprData...)
+			continue
 		}
+		replace[i] = "?"
+		data = append(data, value)
 	}
+	return "(" + strings.Join(replace, ", ") + ")", data
+}
 
-	for _, sqlRune := range sql {
-		if sqlRune == rune('?') {
-			// 将rune替换成 值
-			repData := getData(data, index)
-			newSql = append(newSql, []rune(repData)...)
-			index++
+func (conflict *onConflict) build(c *buildContext) (string, []interface{}) {
+	sql := "on conflict"
+	if conflict.constraint != "" {
+		if !c.dialect.Supports(FeatureOnConstraint) {
+			c.addError(unsupported(c.dialect, FeatureOnConstraint))
+		}
+		sql = fmt.Sprintf("%s on constraint %s", sql, c.ident(conflict.constraint))
+	} else if len(conflict.columns) > 0 {
+		sql = fmt.Sprintf("%s (%s)", sql, strings.Join(c.idents(conflict.columns), ", "))
+	}
+	// do nothing与do update只能且必须指定一个
+	switch {
+	case conflict.doNothing && len(conflict.sets) > 0:
+		c.addError(fmt.Errorf("%w: on conflict with both do nothing and do update", ErrInvalidValue))
+	case !conflict.doNothing && len(conflict.sets) == 0:
+		c.addError(fmt.Errorf("%w: on conflict without do nothing or do update", ErrInvalidValue))
+	}
+	if conflict.doNothing || len(conflict.sets) == 0 {
+		return sql + " do nothing", nil
+	}
+	// do update必须指定冲突的字段或约束
+	if conflict.constraint == "" && len(conflict.columns) == 0 {
+		c.addError(fmt.Errorf("%w: on conflict do update requires conflict columns or constraint", ErrInvalidValue))
+	}
+
+	sets, data := buildSets(c, conflict.sets, "excluded")
+	sql = fmt.Sprintf("%s do update set %s", sql, sets)
+	if conflict.where != nil {
+		where, whereData := buildWith(conflict.where, c)
+		if where != "" {
+			sql = fmt.Sprintf("%s where %s", sql, where)
+			data = append(data, whereData...)
+		}
+	}
+	return sql, data
+}
+
+// buildSets 构建 column = value, ... 语句
+// rowAlias 为Inserted引用的待插入行的别名，为空时构建为 values(column)
+func buildSets(c *buildContext, sets []*setStat, rowAlias string) (string, []interface{}) {
+	data := make([]interface{}, 0, len(sets))
+	fields := make([]string, 0, len(sets))
+	for _, set := range sets {
+		if inserted, ok := set.value.(*insertedExpr); ok {
+			fields = append(fields, c.ident(set.column)+" = "+inserted.ref(c, rowAlias))
+		} else if t, ok := set.value.(Builder); ok {
+			expr, exprData := buildWith(t, c)
+			fields = append(fields, c.ident(set.column)+" = "+expr)
+			data = append(data, exprData...)
 		} else {
-			newSql = append(newSql, sqlRune)
+			fields = append(fields, c.ident(set.column)+" = ?")
+			data = append(data, set.value)
 		}
 	}
-	return string(newSql)
+	return strings.Join(fields, ", "), data
+}
+
+// String 返回参数插值后的sql，构建过程中有错误时返回空字符串
+func (builder InsertBuilder) String() string {
+	c := newBuildContext(builder.dialect, builder.quote)
+	sql, data := builder.build(c)
+	if c.err() != nil {
+		return ""
+	}
+	return interpolate(sql, data)
 }
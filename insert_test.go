@@ -1,8 +1,12 @@
 package sqlbuilder_test
 
 import (
+	"context"
+	"errors"
+	"reflect"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/sureyee/sqlbuilder"
 )
 
@@ -15,28 +19,460 @@ func TestInsert(t *testing.T) {
 }
 
 func TestInsertFields(t *testing.T) {
-	sql1 := "insert into users (username, age) values (\"zhangsan\", 10)"
-	sql2 := "insert into users (age, username) values (10, \"zhangsan\")"
+	sql := "insert into users (username, age) values (\"zhangsan\", 10)"
 	builderSql := sqlbuilder.Insert("users").Fields("username", "age").Values("zhangsan", 10).String()
-	if sql1 == builderSql {
-		return
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestInsertMap(t *testing.T) {
+	sql := "insert into users (age, status, username) values (10, 1, \"zhangsan\")"
+	for i := 0; i < 10; i++ {
+		builderSql := sqlbuilder.Insert("users").Map(map[string]interface{}{
+			"username": "zhangsan",
+			"status":   1,
+			"age":      10,
+		}).String()
+		if sql != builderSql {
+			t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+		}
 	}
+}
 
-	if sql2 == builderSql {
-		return
+func TestInsertIgnore(t *testing.T) {
+	sql := "insert ignore into users (username) values (?)"
+	builderSql, _ := sqlbuilder.Insert("users").Ignore().Fields("username").Values("zhangsan").Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
 	}
 
-	t.Errorf("expected:`%v` or `%v`, got:`%v`", sql1, sql2, builderSql)
+	sql = "insert or ignore into users (username) values (?)"
+	builderSql, _ = sqlbuilder.Insert("users").Ignore().Fields("username").Values("zhangsan").Dialect(sqlbuilder.SQLite).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	sql = "insert into users (username) values ($1), ($2) on conflict do nothing"
+	builderSql, _ = sqlbuilder.Insert("users").Ignore().Fields("username").AddRow("zhangsan").AddRow("lisi").Dialect(sqlbuilder.PostgreSQL).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	err := sqlbuilder.Insert("users").Ignore().Fields("username").Values("zhangsan").Dialect(sqlbuilder.SQLServer).Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+	}
 }
 
-func TestInsertMap(t *testing.T) {
-	sql1 := "insert into users (username, age) values (\"zhangsan\", 10)"
-	sql2 := "insert into users (age, username) values (10, \"zhangsan\")"
-	builderSql := sqlbuilder.Insert("users").Map(map[string]interface{}{
-		"username": "zhangsan",
-		"age":      10,
-	}).String()
-	if sql1 != builderSql && sql2 != builderSql {
-		t.Errorf("expected:`%v` or `%v`, got:`%v`", sql1, sql2, builderSql)
+func TestReplace(t *testing.T) {
+	sql := "replace into users (id, username) values (?, ?)"
+	builderSql, _ := sqlbuilder.Replace("users").Fields("id", "username").Values(1, "zhangsan").Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	sql = "insert or replace into users (id, username) values (?, ?)"
+	builderSql, _ = sqlbuilder.Replace("users").Fields("id", "username").Values(1, "zhangsan").Dialect(sqlbuilder.SQLite).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	err := sqlbuilder.Replace("users").Fields("id", "username").Values(1, "zhangsan").Dialect(sqlbuilder.PostgreSQL).Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+	}
+}
+
+func TestInsertOnConflictReturning(t *testing.T) {
+	sql := "insert into users (id, username) values (?, ?) on conflict (id) do update set username = ?, age = age + ? returning id"
+	builderSql, builderData := sqlbuilder.Insert("users").Fields("id", "username").Values(1, "zhangsan").
+		OnConflict("id").
+		DoUpdateSet("username", "zhangsan").
+		DoUpdateSet("age", sqlbuilder.Raw("age + ?", 1)).
+		Returning("id").
+		Dialect(sqlbuilder.SQLite).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if len(builderData) != 4 {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+}
+
+func TestInsertRows(t *testing.T) {
+	sql := "insert into users (username, age) values (\"zhangsan\", 10), (\"lisi\", 11), (\"wangwu\", 12)"
+	builderSql := sqlbuilder.Insert("users").Fields("username", "age").
+		AddRow("zhangsan", 10).
+		Rows([]interface{}{"lisi", 11}, []interface{}{"wangwu", 12}).
+		String()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestInsertRowsMismatch(t *testing.T) {
+	err := sqlbuilder.Insert("users").Fields("username", "age").
+		AddRow("zhangsan", 10).
+		AddRow("lisi").
+		Err()
+	if !errors.Is(err, sqlbuilder.ErrFieldsMismatch) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrFieldsMismatch, err)
+	}
+}
+
+func TestInsertStatements(t *testing.T) {
+	dialect := sqlbuilder.NewSQLiteDialectWithOptions(sqlbuilder.SQLiteOptions{MaxPlaceholders: 5})
+	builder := sqlbuilder.Insert("users").Fields("username", "age").
+		AddRow("zhangsan", 10).
+		AddRow("lisi", 11).
+		AddRow("wangwu", 12).
+		Dialect(dialect)
+
+	if err := builder.Err(); !errors.Is(err, sqlbuilder.ErrTooManyPlaceholders) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrTooManyPlaceholders, err)
+	}
+
+	statements, err := builder.Statements()
+	if err != nil {
+		t.Fatalf("Statements error: %v", err)
+	}
+	expected := []string{
+		"insert into users (username, age) values (?, ?), (?, ?)",
+		"insert into users (username, age) values (?, ?)",
+	}
+	if len(statements) != len(expected) {
+		t.Fatalf("expected %d statements, got %d", len(expected), len(statements))
+	}
+	for i, statement := range statements {
+		if statement.SQL != expected[i] {
+			t.Errorf("expected:`%v`, got:`%v`", expected[i], statement.SQL)
+		}
+	}
+	if !reflect.DeepEqual(statements[1].Args, []interface{}{"wangwu", 12}) {
+		t.Errorf("unexpected args: %v", statements[1].Args)
+	}
+}
+
+func TestInsertExecContextSplit(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	mock.ExpectExec("insert into users (username, age) values ($1, $2), ($3, $4) on conflict (username) do update set age = $5").
+		WithArgs("zhangsan", 10, "lisi", 11, 1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("insert into users (username, age) values ($1, $2) on conflict (username) do update set age = $3").
+		WithArgs("wangwu", 12, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	exec := sqlbuilder.WithDialect(db, &limitedDialect{Dialect: sqlbuilder.PostgreSQL, max: 5})
+	result, err := sqlbuilder.Insert("users").Fields("username", "age").
+		AddRow("zhangsan", 10).
+		AddRow("lisi", 11).
+		AddRow("wangwu", 12).
+		OnConflict("username").
+		DoUpdateSet("age", 1).
+		ExecContext(context.Background(), exec)
+	if err != nil {
+		t.Fatalf("ExecContext error: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected != 3 {
+		t.Errorf("expected 3 rows affected, got %d", affected)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// limitedDialect 限制参数数量的方言，用于测试拆分语句
+type limitedDialect struct {
+	sqlbuilder.Dialect
+	max int
+}
+
+func (d *limitedDialect) MaxPlaceholders() int {
+	return d.max
+}
+
+func TestInsertOnDuplicateKeyUpdate(t *testing.T) {
+	sql := "insert into users (id, username, login_times) values (?, ?, ?) on duplicate key update username = values(username), login_times = login_times + ?, status = ?"
+	builderSql, builderData := sqlbuilder.Insert("users").Fields("id", "username", "login_times").
+		Values(1, "zhangsan", 1).
+		OnDuplicateKeyUpdate("username", sqlbuilder.Inserted("username")).
+		OnDuplicateKeyUpdate("login_times", sqlbuilder.Raw("login_times + ?", 1)).
+		OnDuplicateKeyUpdate("status", 1).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if !reflect.DeepEqual(builderData, []interface{}{1, "zhangsan", 1, 1, 1}) {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+}
+
+func TestInsertOnDuplicateKeyUpdateRowAlias(t *testing.T) {
+	sql := "insert into `users` (`id`, `username`) values (?, ?), (?, ?) as `new` on duplicate key update `username` = `new`.`username`"
+	builderSql, _ := sqlbuilder.Insert("users").Fields("id", "username").
+		AddRow(1, "zhangsan").
+		AddRow(2, "lisi").
+		RowAlias("new").
+		OnDuplicateKeyUpdate("username", sqlbuilder.Inserted("username")).
+		QuoteIdentifier(true).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	err := sqlbuilder.Insert("users").Fields("id").Values(1).
+		OnDuplicateKeyUpdate("id", sqlbuilder.Inserted("id")).
+		Dialect(sqlbuilder.PostgreSQL).
+		Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+	}
+
+	// 行别名仅mysql可用
+	err = sqlbuilder.Insert("t").Fields("a").Values(1).RowAlias("new").Dialect(sqlbuilder.PostgreSQL).Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+	}
+
+	// 行别名不能用于insert select
+	err = sqlbuilder.Insert("t").Fields("a").
+		FromSelect(sqlbuilder.Select("a").From("s")).
+		RowAlias("new").
+		Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+}
+
+func TestInsertOnConflictDoNothing(t *testing.T) {
+	sql := "insert into users (id, username) values ($1, $2) on conflict (id) do nothing"
+	builderSql, _ := sqlbuilder.Insert("users").Fields("id", "username").Values(1, "zhangsan").
+		OnConflict("id").
+		DoNothing().
+		Dialect(sqlbuilder.PostgreSQL).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	sql = "insert into users (id, username) values ($1, $2) on conflict on constraint users_pkey do nothing"
+	builderSql, _ = sqlbuilder.Insert("users").Fields("id", "username").Values(1, "zhangsan").
+		OnConflictOnConstraint("users_pkey").
+		DoNothing().
+		Dialect(sqlbuilder.PostgreSQL).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	err := sqlbuilder.Insert("users").Fields("id").Values(1).
+		OnConflictOnConstraint("users_pkey").
+		DoNothing().
+		Dialect(sqlbuilder.SQLite).
+		Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+	}
+}
+
+func TestInsertOnConflictDoUpdateWhere(t *testing.T) {
+	sql := "insert into \"users\" (\"id\", \"username\", \"version\") values ($1, $2, $3) " +
+		"on conflict (\"id\") do update set \"username\" = \"excluded\".\"username\", \"version\" = \"excluded\".\"version\" " +
+		"where \"users\".\"version\" < $4 returning \"id\""
+	builderSql, builderData := sqlbuilder.Insert("users").Fields("id", "username", "version").
+		Values(1, "zhangsan", 2).
+		OnConflict("id").
+		DoUpdateSet("username", sqlbuilder.Excluded("username")).
+		DoUpdateSet("version", sqlbuilder.Excluded("version")).
+		DoUpdateWhere(sqlbuilder.WhereOperate("users.version", "<", 2)).
+		Returning("id").
+		Dialect(sqlbuilder.PostgreSQL).
+		QuoteIdentifier(true).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if !reflect.DeepEqual(builderData, []interface{}{1, "zhangsan", 2, 2}) {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+
+	// do update必须指定冲突的字段或约束
+	err := sqlbuilder.Insert("users").Fields("id", "username").Values(1, "zhangsan").
+		OnConflict().
+		DoUpdateSet("username", sqlbuilder.Excluded("username")).
+		Dialect(sqlbuilder.PostgreSQL).
+		Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+
+	// do nothing与do update只能且必须指定一个
+	err = sqlbuilder.Insert("users").Fields("id").Values(1).
+		OnConflict("id").
+		DoUpdateSet("id", sqlbuilder.Excluded("id")).
+		DoNothing().
+		Dialect(sqlbuilder.PostgreSQL).
+		Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+	err = sqlbuilder.Insert("users").Fields("id").Values(1).OnConflict("id").Dialect(sqlbuilder.PostgreSQL).Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+
+	// do nothing可以不指定冲突的字段
+	sql = "insert into users (id) values ($1) on conflict do nothing"
+	builderSql, _ = sqlbuilder.Insert("users").Fields("id").Values(1).OnConflict().DoNothing().Dialect(sqlbuilder.PostgreSQL).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestInsertFromSelect(t *testing.T) {
+	sql := "insert into archive (id, name) select id, name from users where status = $1 and created_at < $2 on conflict (id) do nothing"
+	builderSql, builderData := sqlbuilder.Insert("archive").Fields("id", "name").
+		FromSelect(sqlbuilder.Select("id", "name").From("users").Where("status", 0).WhereOperate("created_at", "<", "2020-01-01")).
+		OnConflict("id").
+		DoNothing().
+		Dialect(sqlbuilder.PostgreSQL).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if !reflect.DeepEqual(builderData, []interface{}{0, "2020-01-01"}) {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+
+	err := sqlbuilder.Insert("archive").Fields("id").Values(1).
+		FromSelect(sqlbuilder.Select("id").From("users")).
+		Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+}
+
+func TestInsertFromSelectExecContext(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	mock.ExpectExec("insert into archive (id, name) select id, name from users where status = ?").
+		WithArgs(0).
+		WillReturnResult(sqlmock.NewResult(0, 5))
+
+	result, err := sqlbuilder.Insert("archive").Fields("id", "name").
+		FromSelect(sqlbuilder.Select("id", "name").From("users").Where("status", 0)).
+		ExecContext(context.Background(), db)
+	if err != nil {
+		t.Fatalf("ExecContext error: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected != 5 {
+		t.Errorf("expected:`%v`, got:`%v`", 5, affected)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+type insertUser struct {
+	ID         int    `db:"id,auto"`
+	Username   string `db:"username"`
+	Mobile     string `db:"mobile,omitempty"`
+	Status     int    `db:"status,default"`
+	CreatedAt  string `db:"created_at,readonly"`
+	Password   string `db:"-"`
+	LoginTimes int
+}
+
+func TestInsertStruct(t *testing.T) {
+	sql := "insert into users (username, login_times) values (?, ?)"
+	builderSql, builderData := sqlbuilder.Insert("users").
+		Struct(&insertUser{Username: "zhangsan", CreatedAt: "2020-01-01", Password: "secret"}).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if !reflect.DeepEqual(builderData, []interface{}{"zhangsan", 0}) {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+
+	sql = "insert into users (id, username, mobile, status, login_times) values (?, ?, ?, ?, ?)"
+	builderSql, builderData = sqlbuilder.Insert("users").
+		Struct(insertUser{ID: 1, Username: "zhangsan", Mobile: "13800000000", Status: 2, LoginTimes: 3}).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if !reflect.DeepEqual(builderData, []interface{}{1, "zhangsan", "13800000000", 2, 3}) {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+
+	err := sqlbuilder.Insert("users").Struct(1).Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+}
+
+func TestInsertStructs(t *testing.T) {
+	sql := "insert into users (username, mobile, status, login_times) values ($1, $2, default, $3), ($4, $5, $6, $7)"
+	builderSql, builderData := sqlbuilder.Insert("users").
+		Structs([]*insertUser{
+			{Username: "zhangsan"},
+			{Username: "lisi", Mobile: "13800000000", Status: 2},
+		}).
+		Dialect(sqlbuilder.PostgreSQL).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if !reflect.DeepEqual(builderData, []interface{}{"zhangsan", "", 0, "lisi", "13800000000", 2, 0}) {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+
+	err := sqlbuilder.Insert("users").Structs(insertUser{}).Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+
+	// sqlite不支持在values中使用default
+	err = sqlbuilder.Insert("users").
+		Structs([]*insertUser{
+			{Username: "zhangsan"},
+			{Username: "lisi", Status: 2},
+		}).
+		Dialect(sqlbuilder.SQLite).
+		Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+	}
+
+	// 所有行均为零值时不插入该字段，sqlite同样可用
+	if err := sqlbuilder.Insert("users").Structs([]*insertUser{{Username: "zhangsan"}}).Dialect(sqlbuilder.SQLite).Err(); err != nil {
+		t.Errorf("expected:`%v`, got:`%v`", nil, err)
+	}
+}
+
+func TestInsertUnsupported(t *testing.T) {
+	builders := []*sqlbuilder.InsertBuilder{
+		sqlbuilder.Replace("users").Fields("id").Values(1).Dialect(sqlbuilder.SQLServer),
+		sqlbuilder.Insert("users").Fields("id").Values(1).OnConflict("id").DoUpdateSet("id", 1).Dialect(sqlbuilder.MySQL),
+		sqlbuilder.Insert("users").Fields("id").Values(1).Returning("id").Dialect(sqlbuilder.MySQL),
+	}
+	for _, builder := range builders {
+		if err := builder.Err(); !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+			t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+		}
+		if builderSql, builderData := builder.Build(); builderSql != "" || builderData != nil {
+			t.Errorf("expected empty result, got:`%v` %v", builderSql, builderData)
+		}
 	}
 }
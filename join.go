@@ -9,6 +9,12 @@ type Join struct {
 }
 
 func (builder *Join) Build() (string, []interface{}) {
-	sql, data := builder.on.Build()
-	return fmt.Sprintf("%s join %s on %s", builder.link, builder.table, sql), data
+	c := newBuildContext(nil, nil)
+	sql, data := builder.build(c)
+	return rebind(c.dialect, sql), data
+}
+
+func (builder *Join) build(c *buildContext) (string, []interface{}) {
+	sql, data := buildWith(builder.on, c)
+	return fmt.Sprintf("%s join %s on %s", builder.link, c.ident(builder.table), sql), data
 }
@@ -0,0 +1,51 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"strings"
+)
+
+type mysqlDialect struct{}
+
+func (d *mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (d *mysqlDialect) Placeholder(n int) string {
+	return "?"
+}
+
+func (d *mysqlDialect) Paginate(offset, limit int, ordered bool) (string, string, error) {
+	return "", fmt.Sprintf("limit %d, %d", offset, limit), nil
+}
+
+func (d *mysqlDialect) Lock(locker Locker) (string, string, error) {
+	sql, _ := locker.Build()
+	return "", sql, nil
+}
+
+func (d *mysqlDialect) Insert(mode InsertMode) (string, error) {
+	switch mode {
+	case InsertIgnore:
+		return "insert ignore into", nil
+	case InsertReplace:
+		return "replace into", nil
+	}
+	return "insert into", nil
+}
+
+func (d *mysqlDialect) MaxPlaceholders() int {
+	return 65535
+}
+
+func (d *mysqlDialect) Supports(feature Feature) bool {
+	switch feature {
+	case FeatureOnDuplicateKey, FeatureUpdateJoin, FeatureDeleteJoin, FeatureWriteLimit, FeatureAnyAll, FeatureDefaultValue:
+		return true
+	}
+	return false
+}
+
+func (d *mysqlDialect) Quote(ident string) string {
+	return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+}
@@ -0,0 +1,59 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type postgresDialect struct{}
+
+func (d *postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (d *postgresDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (d *postgresDialect) Paginate(offset, limit int, ordered bool) (string, string, error) {
+	sql := ""
+	if limit > 0 {
+		sql = fmt.Sprintf("limit %d", limit)
+	}
+	if offset > 0 {
+		sql = strings.TrimSpace(fmt.Sprintf("%s offset %d", sql, offset))
+	}
+	return "", sql, nil
+}
+
+func (d *postgresDialect) Lock(locker Locker) (string, string, error) {
+	if _, ok := locker.(*ShareLocker); ok {
+		return "", "for share", nil
+	}
+	sql, _ := locker.Build()
+	return "", sql, nil
+}
+
+func (d *postgresDialect) Insert(mode InsertMode) (string, error) {
+	if mode != InsertDefault {
+		return "", unsupported(d, mode)
+	}
+	return "insert into", nil
+}
+
+func (d *postgresDialect) MaxPlaceholders() int {
+	return 65535
+}
+
+func (d *postgresDialect) Supports(feature Feature) bool {
+	switch feature {
+	case FeatureReturning, FeatureOnConflict, FeatureOnConstraint, FeatureUpdateFrom, FeatureDeleteUsing, FeatureAnyAll, FeatureArray, FeatureDefaultValue:
+		return true
+	}
+	return false
+}
+
+func (d *postgresDialect) Quote(ident string) string {
+	return "\"" + strings.ReplaceAll(ident, "\"", "\"\"") + "\""
+}
@@ -0,0 +1,26 @@
+package sqlbuilder
+
+import (
+	"strings"
+)
+
+// buildReturning 构建返回结果的子句
+// 支持returning的方言返回suffix，添加在语句末尾；
+// sqlserver返回output，添加在values/where之前，pseudo为引用的伪表，inserted或deleted
+func buildReturning(c *buildContext, columns []string, pseudo string) (output, suffix string) {
+	if len(columns) == 0 {
+		return "", ""
+	}
+	switch {
+	case c.dialect.Supports(FeatureReturning):
+		return "", "returning " + strings.Join(c.idents(columns), ", ")
+	case c.dialect.Supports(FeatureOutput):
+		outputs := make([]string, len(columns))
+		for i, column := range columns {
+			outputs[i] = c.ident(pseudo + "." + column)
+		}
+		return "output " + strings.Join(outputs, ", "), ""
+	}
+	c.addError(unsupported(c.dialect, FeatureReturning))
+	return "", ""
+}
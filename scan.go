@@ -0,0 +1,293 @@
+package sqlbuilder
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// UnknownColumnPolicy 扫描结果时遇到结构体中不存在的字段的处理方式
+type UnknownColumnPolicy int
+
+const (
+	// IgnoreUnknownColumns 忽略结构体中不存在的字段
+	IgnoreUnknownColumns UnknownColumnPolicy = iota
+	// ErrorOnUnknownColumns 结构体中不存在的字段将返回ErrUnknownColumn
+	ErrorOnUnknownColumns
+)
+
+var defaultUnknownColumnPolicy = IgnoreUnknownColumns
+
+// SetUnknownColumnPolicy 设置全局的未知字段处理方式
+func SetUnknownColumnPolicy(policy UnknownColumnPolicy) {
+	defaultUnknownColumnPolicy = policy
+}
+
+// structField 结构体字段与数据库字段的映射
+type structField struct {
+	name    string
+	index   []int
+	options []string
+	depth   int
+}
+
+// hasOption 是否包含db标签的选项，如 `db:"id,omitempty"`
+func (field *structField) hasOption(option string) bool {
+	for _, o := range field.options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+var structFieldsCache sync.Map
+
+// structFields 获取结构体的字段映射，按字段声明的顺序返回
+// 字段名使用db标签，没有标签时使用字段名的蛇形命名，标签为-的字段将被忽略，
+// 匿名嵌入的结构体字段将被展开，同名字段以层级较浅的为准
+func structFields(t reflect.Type) []*structField {
+	if cached, ok := structFieldsCache.Load(t); ok {
+		return cached.([]*structField)
+	}
+	all := walkStructFields(t, nil, 0)
+	seen := make(map[string]*structField, len(all))
+	for _, field := range all {
+		if exist, ok := seen[field.name]; !ok || field.depth < exist.depth {
+			seen[field.name] = field
+		}
+	}
+	fields := make([]*structField, 0, len(seen))
+	for _, field := range all {
+		if seen[field.name] == field {
+			fields = append(fields, field)
+		}
+	}
+	structFieldsCache.Store(t, fields)
+	return fields
+}
+
+func walkStructFields(t reflect.Type, index []int, depth int) []*structField {
+	fields := make([]*structField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := strings.TrimSpace(parts[0])
+		fieldIndex := append(append(make([]int, 0, len(index)+1), index...), i)
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			if f.PkgPath != "" && f.Type.Kind() == reflect.Ptr {
+				// 未导出的结构体指针无法初始化
+				continue
+			}
+			fields = append(fields, walkStructFields(ft, fieldIndex, depth+1)...)
+			continue
+		}
+		if f.PkgPath != "" {
+			// 未导出字段
+			continue
+		}
+		if name == "" {
+			name = snakeCase(f.Name)
+		}
+		fields = append(fields, &structField{
+			name:    name,
+			index:   fieldIndex,
+			options: parts[1:],
+			depth:   depth,
+		})
+	}
+	return fields
+}
+
+// snakeCase UserName => user_name, ID => id
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// fieldByIndex 获取嵌套字段，途经的nil指针将被初始化
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// indirectValue 获取指针或接口指向的值，nil时返回无效的值
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// fieldValue 获取嵌套字段的值，途经nil指针时返回false
+func fieldValue(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// defaultValue 使用数据库的默认值
+var defaultValue = Raw("default")
+
+// structRows 将同类型的结构体转换为待插入的字段和行，字段按声明顺序排列
+func structRows(values []reflect.Value) ([]string, [][]interface{}) {
+	fields := structFields(values[0].Type())
+	columns := make([]string, 0, len(fields))
+	rows := make([][]interface{}, len(values))
+	for _, field := range fields {
+		if field.hasOption("readonly") {
+			continue
+		}
+		useDefault := field.hasOption("auto") || field.hasOption("default")
+		omitEmpty := useDefault || field.hasOption("omitempty")
+		column := make([]interface{}, len(values))
+		empty := true
+		for i, v := range values {
+			fv, ok := fieldValue(v, field.index)
+			switch {
+			case ok && !fv.IsZero():
+				empty = false
+				column[i] = fv.Interface()
+			case useDefault:
+				column[i] = defaultValue
+			case ok:
+				column[i] = fv.Interface()
+			}
+		}
+		if omitEmpty && empty {
+			continue
+		}
+		columns = append(columns, field.name)
+		for i := range rows {
+			rows[i] = append(rows[i], column[i])
+		}
+	}
+	return columns, rows
+}
+
+// scanStruct 将当前行扫描到结构体中
+func scanStruct(rows *sql.Rows, v reflect.Value, policy UnknownColumnPolicy) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	fields := make(map[string]*structField)
+	for _, field := range structFields(v.Type()) {
+		fields[strings.ToLower(field.name)] = field
+	}
+	targets := make([]interface{}, len(columns))
+	for i, column := range columns {
+		field, ok := fields[strings.ToLower(column)]
+		if !ok {
+			if policy == ErrorOnUnknownColumns {
+				return fmt.Errorf("%w: %s", ErrUnknownColumn, column)
+			}
+			targets[i] = new(interface{})
+			continue
+		}
+		targets[i] = fieldByIndex(v, field.index).Addr().Interface()
+	}
+	return rows.Scan(targets...)
+}
+
+// structDest 检查扫描目标，dst必须为结构体指针
+func structDest(dst interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return v, fmt.Errorf("%w: scan destination must be a pointer to struct, got %T", ErrInvalidDestination, dst)
+	}
+	return v.Elem(), nil
+}
+
+// sliceDest 检查扫描目标，dst必须为结构体切片或结构体指针切片的指针
+func sliceDest(dst interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return v, fmt.Errorf("%w: scan destination must be a pointer to slice, got %T", ErrInvalidDestination, dst)
+	}
+	elemType := v.Elem().Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return v, fmt.Errorf("%w: scan destination must be a slice of struct, got %T", ErrInvalidDestination, dst)
+	}
+	return v.Elem(), nil
+}
+
+// scanOne 将第一行结果扫描到结构体中，没有结果时返回sql.ErrNoRows
+func scanOne(rows *sql.Rows, v reflect.Value, policy UnknownColumnPolicy) error {
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if err := scanStruct(rows, v, policy); err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+// scanAll 将所有结果追加到切片中
+func scanAll(rows *sql.Rows, slice reflect.Value, policy UnknownColumnPolicy) error {
+	defer rows.Close()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	for rows.Next() {
+		elem := reflect.New(elemType)
+		if err := scanStruct(rows, elem.Elem(), policy); err != nil {
+			return err
+		}
+		if isPtr {
+			slice.Set(reflect.Append(slice, elem))
+		} else {
+			slice.Set(reflect.Append(slice, elem.Elem()))
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,172 @@
+package sqlbuilder_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sureyee/sqlbuilder"
+)
+
+type timestamps struct {
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type Profile struct {
+	Bio string `db:"bio"`
+}
+
+type account struct {
+	timestamps
+	*Profile
+	ID       int            `db:"id"`
+	Username string         `db:"username"`
+	Mobile   sql.NullString `db:"mobile"`
+	Age      *int           `db:"age"`
+	Status   int8
+	Password string `db:"-"`
+}
+
+func TestScanStruct(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "username", "mobile", "age", "status", "created_at", "bio", "password"}).
+		AddRow(1, "zhangsan", nil, 10, 1, now, "hello", "secret")
+	mock.ExpectQuery("select * from users where id = ?").WithArgs(1).WillReturnRows(rows)
+
+	var data account
+	err = sqlbuilder.Select("*").From("users").Where("id", 1).ScanStruct(context.Background(), db, &data)
+	if err != nil {
+		t.Fatalf("ScanStruct error: %v", err)
+	}
+	if data.ID != 1 || data.Username != "zhangsan" || data.Status != 1 {
+		t.Errorf("unexpected data: %+v", data)
+	}
+	if data.Mobile.Valid {
+		t.Errorf("expected null mobile, got: %v", data.Mobile)
+	}
+	if data.Age == nil || *data.Age != 10 {
+		t.Errorf("expected age 10, got: %v", data.Age)
+	}
+	if !data.CreatedAt.Equal(now) {
+		t.Errorf("expected created_at %v, got: %v", now, data.CreatedAt)
+	}
+	if data.Profile == nil || data.Bio != "hello" {
+		t.Errorf("expected bio `hello`, got: %v", data.Profile)
+	}
+	if data.Password != "" {
+		t.Errorf("expected password ignored, got: %v", data.Password)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestScanStructNoRows(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("select * from users where id = ?").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	var data account
+	err = sqlbuilder.Select("*").From("users").Where("id", 1).ScanStruct(context.Background(), db, &data)
+	if err != sql.ErrNoRows {
+		t.Errorf("expected:`%v`, got:`%v`", sql.ErrNoRows, err)
+	}
+}
+
+func TestScanStructs(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("select id, username from users").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "username"}).AddRow(1, "zhangsan").AddRow(2, "lisi"),
+	)
+	mock.ExpectQuery("select id, username from users").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "username"}).AddRow(1, "zhangsan").AddRow(2, "lisi"),
+	)
+
+	var list []account
+	if err := sqlbuilder.Select("id", "username").From("users").ScanStructs(context.Background(), db, &list); err != nil {
+		t.Fatalf("ScanStructs error: %v", err)
+	}
+	if len(list) != 2 || list[1].Username != "lisi" {
+		t.Errorf("unexpected data: %+v", list)
+	}
+
+	var ptrs []*account
+	if err := sqlbuilder.Select("id", "username").From("users").ScanStructs(context.Background(), db, &ptrs); err != nil {
+		t.Fatalf("ScanStructs error: %v", err)
+	}
+	if len(ptrs) != 2 || ptrs[0].ID != 1 {
+		t.Errorf("unexpected data: %+v", ptrs)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestScanUnknownColumn(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("select id, nickname from users").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "nickname"}).AddRow(1, "zs"),
+	)
+
+	var data account
+	err = sqlbuilder.Select("id", "nickname").From("users").
+		UnknownColumns(sqlbuilder.ErrorOnUnknownColumns).
+		ScanStruct(context.Background(), db, &data)
+	if !errors.Is(err, sqlbuilder.ErrUnknownColumn) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnknownColumn, err)
+	}
+
+	err = sqlbuilder.Select("id").From("users").ScanStruct(context.Background(), db, data)
+	if !errors.Is(err, sqlbuilder.ErrInvalidDestination) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidDestination, err)
+	}
+}
+
+func TestScanStructReturning(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("insert into users (id, username) values ($1, $2) on conflict (id) do update set username = excluded.username returning id, username, status").
+		WithArgs(1, "zhangsan").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "status"}).AddRow(1, "zhangsan", 2))
+
+	var data account
+	err = sqlbuilder.Insert("users").Fields("id", "username").Values(1, "zhangsan").
+		OnConflict("id").
+		DoUpdateSet("username", sqlbuilder.Excluded("username")).
+		Returning("id", "username", "status").
+		ScanStruct(context.Background(), sqlbuilder.WithDialect(db, sqlbuilder.PostgreSQL), &data)
+	if err != nil {
+		t.Fatalf("ScanStruct error: %v", err)
+	}
+	if data.ID != 1 || data.Username != "zhangsan" || data.Status != 2 {
+		t.Errorf("unexpected data: %+v", data)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
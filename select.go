@@ -1,9 +1,9 @@
 package sqlbuilder
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"reflect"
-	"strconv"
 	"strings"
 )
 
@@ -17,75 +17,259 @@ type SelectBuilder struct {
 	sql     string
 	table   string
 	join    []*Join
-	fields  []string
-	order   []string
+	fields  []interface{}
+	order   []*orderStat
 	groupBy []string
 	locker  Locker
+	dialect Dialect
+	quote   *bool
+	unknown *UnknownColumnPolicy
+	err     error
 	data    []interface{}
 }
 
+type orderStat struct {
+	column interface{}
+	sort   string
+}
+
+// newOrderStat 排序规则只能是asc或desc，错误的排序规则返回nil
+func newOrderStat(column interface{}, sort string) *orderStat {
+	sort = strings.ToLower(sort)
+	if sort != "desc" && sort != "asc" {
+		return nil
+	}
+	return &orderStat{
+		column: column,
+		sort:   sort,
+	}
+}
+
+// buildOrder 构建order by之后的排序字段
+// 字段名始终经过校验，Raw表达式原样输出
+func buildOrder(c *buildContext, orders []*orderStat) (string, []interface{}) {
+	order := make([]string, len(orders))
+	data := make([]interface{}, 0)
+	for i, o := range orders {
+		switch column := o.column.(type) {
+		case string:
+			order[i] = c.strictIdent(column)
+		case Builder:
+			expr, exprData := buildWith(column, c)
+			order[i] = expr
+			data = append(data, exprData...)
+		default:
+			c.addError(fmt.Errorf("%w: order by expects a column or Raw, got %T", ErrInvalidValue, o.column))
+		}
+		order[i] += " " + o.sort
+	}
+	return strings.Join(order, ", "), data
+}
+
+// Build 构建sql，构建过程中有错误时返回空sql，错误可以通过BuildE或Err获取
 func (builder *SelectBuilder) Build() (string, []interface{}) {
+	if err := builder.buildE(); err != nil {
+		return "", nil
+	}
+	return builder.sql, builder.data
+}
+
+// BuildE 构建sql，构建过程中的错误将被返回
+func (builder *SelectBuilder) BuildE() (string, []interface{}, error) {
+	if err := builder.buildE(); err != nil {
+		return "", nil, err
+	}
+	return builder.sql, builder.data, nil
+}
+
+// Err 返回构建sql时的错误
+func (builder *SelectBuilder) Err() error {
+	return builder.buildE()
+}
+
+// buildFor 按执行器的方言构建sql
+func (builder *SelectBuilder) buildFor(exec Executor) (string, []interface{}, error) {
+	c := newBuildContext(executorDialect(builder.dialect, exec), builder.quote)
+	sql, data := builder.build(c)
+	return rebind(c.dialect, sql), data, c.err()
+}
+
+// QueryContext 构建并执行查询
+func (builder *SelectBuilder) QueryContext(ctx context.Context, exec Executor) (*sql.Rows, error) {
+	query, args, err := builder.buildFor(exec)
+	return queryContext(ctx, exec, query, args, err)
+}
+
+// QueryRowContext 构建并执行查询，返回一行结果
+func (builder *SelectBuilder) QueryRowContext(ctx context.Context, exec Executor) *Row {
+	query, args, err := builder.buildFor(exec)
+	return queryRowContext(ctx, exec, query, args, err)
+}
+
+// ScanStruct 执行查询并将第一行结果扫描到结构体中，dst必须为结构体指针
+// 结构体字段通过db标签与结果字段对应，没有结果时返回sql.ErrNoRows
+func (builder *SelectBuilder) ScanStruct(ctx context.Context, exec Executor, dst interface{}) error {
+	v, err := structDest(dst)
+	if err != nil {
+		return err
+	}
+	rows, err := builder.QueryContext(ctx, exec)
+	if err != nil {
+		return err
+	}
+	return scanOne(rows, v, builder.getUnknownColumnPolicy())
+}
+
+// ScanStructs 执行查询并将所有结果扫描到切片中，dst必须为 *[]T 或 *[]*T
+func (builder *SelectBuilder) ScanStructs(ctx context.Context, exec Executor, dst interface{}) error {
+	v, err := sliceDest(dst)
+	if err != nil {
+		return err
+	}
+	rows, err := builder.QueryContext(ctx, exec)
+	if err != nil {
+		return err
+	}
+	return scanAll(rows, v, builder.getUnknownColumnPolicy())
+}
+
+// UnknownColumns 指定扫描结果时遇到结构体中不存在的字段的处理方式，未指定时使用全局设置
+func (builder *SelectBuilder) UnknownColumns(policy UnknownColumnPolicy) *SelectBuilder {
+	builder.unknown = &policy
+	return builder
+}
+
+func (builder *SelectBuilder) getUnknownColumnPolicy() UnknownColumnPolicy {
+	if builder.unknown == nil {
+		return defaultUnknownColumnPolicy
+	}
+	return *builder.unknown
+}
+
+func (builder *SelectBuilder) buildE() error {
 	if !builder.isBuilt {
-		sql := fmt.Sprintf("select %s from %s", strings.Join(builder.fields, ", "), builder.table)
-		// 构建join
-		if len(builder.join) > 0 {
-			for _, j := range builder.join {
-				join, joinData := j.Build()
-				sql = sql + " " + join
-				builder.data = append(builder.data, joinData...)
-			}
-		}
+		c := newBuildContext(builder.dialect, builder.quote)
+		sql, data := builder.build(c)
+		builder.sql = rebind(c.dialect, sql)
+		builder.data = data
+		builder.err = c.err()
+		builder.isBuilt = true
+	}
+	return builder.err
+}
 
-		// 构建where语句
-		if builder.where != nil {
-			where, whereData := builder.where.Build()
-			if where != "" {
-				sql = fmt.Sprintf("%s where %s", sql, where)
-				builder.data = append(builder.data, whereData...)
-			}
-		}
-		// 构建group by
-		if len(builder.groupBy) > 0 {
-			sql = fmt.Sprintf("%s group by %s", sql, strings.Join(builder.groupBy, ", "))
+func (builder *SelectBuilder) build(c *buildContext) (string, []interface{}) {
+	data := make([]interface{}, 0)
+	if builder.table == "" {
+		c.addError(ErrEmptyTable)
+	}
+	var top, limit, hint, lock string
+	var err error
+	if builder.limit > 0 || builder.offset > 0 {
+		top, limit, err = c.dialect.Paginate(builder.offset, builder.limit, len(builder.order) > 0)
+		c.addError(err)
+	}
+	if builder.locker != nil {
+		hint, lock, err = c.dialect.Lock(builder.locker)
+		c.addError(err)
+	}
+
+	sql := "select"
+	if top != "" {
+		sql = sql + " " + top
+	}
+	fields := make([]string, len(builder.fields))
+	for i, field := range builder.fields {
+		switch field := field.(type) {
+		case string:
+			fields[i] = c.ident(field)
+		case Builder:
+			expr, exprData := buildWith(field, c)
+			fields[i] = expr
+			data = append(data, exprData...)
 		}
-		// 构建having
-		if builder.having != nil {
-			having, havingData := builder.having.Build()
-			sql = fmt.Sprintf("%s having %s", sql, having)
-			builder.data = append(builder.data, havingData...)
+	}
+	sql = fmt.Sprintf("%s %s from %s", sql, strings.Join(fields, ", "), c.ident(builder.table))
+	if hint != "" {
+		sql = sql + " " + hint
+	}
+	// 构建join
+	if len(builder.join) > 0 {
+		for _, j := range builder.join {
+			join, joinData := j.build(c)
+			sql = sql + " " + join
+			data = append(data, joinData...)
 		}
+	}
 
-		if len(builder.order) > 0 {
-			sql = fmt.Sprintf("%s order by %s", sql, strings.Join(builder.order, ", "))
+	// 构建where语句
+	if builder.where != nil {
+		where, whereData := buildWith(builder.where, c)
+		if where != "" {
+			sql = fmt.Sprintf("%s where %s", sql, where)
+			data = append(data, whereData...)
 		}
+	}
+	// 构建group by
+	if len(builder.groupBy) > 0 {
+		sql = fmt.Sprintf("%s group by %s", sql, strings.Join(c.idents(builder.groupBy), ", "))
+	}
+	// 构建having
+	if builder.having != nil {
+		having, havingData := buildWith(builder.having, c)
+		sql = fmt.Sprintf("%s having %s", sql, having)
+		data = append(data, havingData...)
+	}
 
-		if builder.limit > 0 || builder.offset > 0 {
-			sql = fmt.Sprintf("%s limit %d, %d", sql, builder.offset, builder.limit)
-		}
+	if len(builder.order) > 0 {
+		order, orderData := buildOrder(c, builder.order)
+		sql = fmt.Sprintf("%s order by %s", sql, order)
+		data = append(data, orderData...)
+	}
 
-		if builder.locker != nil {
-			lockSql, _ := builder.locker.Build()
-			sql = fmt.Sprintf("%s %s", sql, lockSql)
-		}
+	if limit != "" {
+		sql = fmt.Sprintf("%s %s", sql, limit)
+	}
 
-		builder.sql = sql
-		builder.isBuilt = true
+	if lock != "" {
+		sql = fmt.Sprintf("%s %s", sql, lock)
 	}
-	return builder.sql, builder.data
+	return sql, data
 }
 
 func Select(fields ...string) *SelectBuilder {
 	builder := &SelectBuilder{
-		fields: fields,
+		fields: make([]interface{}, 0, len(fields)),
+	}
+	for _, field := range fields {
+		builder.fields = append(builder.fields, field)
 	}
 	return builder
 }
 
+// SelectRaw 追加原样输出的查询字段，不做引用，如 SelectRaw("now()")、SelectRaw("coalesce(nickname, ?) as name", "guest")
+func (builder *SelectBuilder) SelectRaw(expr string, data ...interface{}) *SelectBuilder {
+	builder.fields = append(builder.fields, Raw(expr, data...))
+	return builder
+}
+
 func (builder *SelectBuilder) From(table string) *SelectBuilder {
 	builder.table = table
 	return builder
 }
 
+// Dialect 指定构建时使用的方言，未指定时使用全局默认方言
+func (builder *SelectBuilder) Dialect(d Dialect) *SelectBuilder {
+	builder.dialect = d
+	return builder
+}
+
+// QuoteIdentifier 指定是否引用表名、字段名等标识符，未指定时使用全局设置
+func (builder *SelectBuilder) QuoteIdentifier(quote bool) *SelectBuilder {
+	builder.quote = &quote
+	return builder
+}
+
 func (builder *SelectBuilder) getWhere() WhereInterface {
 	if builder.where == nil {
 		builder.where = &WhereBuilder{}
@@ -152,13 +336,142 @@ func (builder *SelectBuilder) OrWhereFunc(f BuilderFunc) *SelectBuilder {
 	return builder
 }
 
-func (builder *SelectBuilder) OrderBy(column, sort string) *SelectBuilder {
-	sort = strings.ToLower(sort)
-	if sort != "desc" && sort != "asc" {
-		// 忽略错误的排序规则
-		return builder
+func (builder *SelectBuilder) WhereColumn(column1, column2 string) *SelectBuilder {
+	builder.getWhere().WhereColumn(column1, column2)
+	return builder
+}
+
+func (builder *SelectBuilder) WhereColumnOperate(column1, operate, column2 string) *SelectBuilder {
+	builder.getWhere().WhereColumnOperate(column1, operate, column2)
+	return builder
+}
+
+func (builder *SelectBuilder) OrWhereColumn(column1, column2 string) *SelectBuilder {
+	builder.getWhere().OrWhereColumn(column1, column2)
+	return builder
+}
+
+func (builder *SelectBuilder) OrWhereColumnOperate(column1, operate, column2 string) *SelectBuilder {
+	builder.getWhere().OrWhereColumnOperate(column1, operate, column2)
+	return builder
+}
+
+func (builder *SelectBuilder) OrWhereIn(column string, value interface{}) *SelectBuilder {
+	builder.getWhere().OrWhereIn(column, value)
+	return builder
+}
+
+func (builder *SelectBuilder) OrWhereBetween(column string, min, max interface{}) *SelectBuilder {
+	builder.getWhere().OrWhereBetween(column, min, max)
+	return builder
+}
+
+func (builder *SelectBuilder) OrWhereLike(column string, value interface{}) *SelectBuilder {
+	builder.getWhere().OrWhereLike(column, value)
+	return builder
+}
+
+func (builder *SelectBuilder) OrWhereNull(column string) *SelectBuilder {
+	builder.getWhere().OrWhereNull(column)
+	return builder
+}
+
+func (builder *SelectBuilder) OrWhereNotNull(column string) *SelectBuilder {
+	builder.getWhere().OrWhereNotNull(column)
+	return builder
+}
+
+// WhereExists where exists (select ...)
+func (builder *SelectBuilder) WhereExists(f BuilderFunc) *SelectBuilder {
+	builder.getWhere().WhereExists(f)
+	return builder
+}
+
+func (builder *SelectBuilder) WhereNotExists(f BuilderFunc) *SelectBuilder {
+	builder.getWhere().WhereNotExists(f)
+	return builder
+}
+
+func (builder *SelectBuilder) OrWhereExists(f BuilderFunc) *SelectBuilder {
+	builder.getWhere().OrWhereExists(f)
+	return builder
+}
+
+func (builder *SelectBuilder) OrWhereNotExists(f BuilderFunc) *SelectBuilder {
+	builder.getWhere().OrWhereNotExists(f)
+	return builder
+}
+
+// WhereAny where column > any (select ...)
+func (builder *SelectBuilder) WhereAny(column, operate string, value interface{}) *SelectBuilder {
+	builder.getWhere().WhereAny(column, operate, value)
+	return builder
+}
+
+// WhereAll where column > all (select ...)
+func (builder *SelectBuilder) WhereAll(column, operate string, value interface{}) *SelectBuilder {
+	builder.getWhere().WhereAll(column, operate, value)
+	return builder
+}
+
+func (builder *SelectBuilder) OrWhereAny(column, operate string, value interface{}) *SelectBuilder {
+	builder.getWhere().OrWhereAny(column, operate, value)
+	return builder
+}
+
+func (builder *SelectBuilder) OrWhereAll(column, operate string, value interface{}) *SelectBuilder {
+	builder.getWhere().OrWhereAll(column, operate, value)
+	return builder
+}
+
+func (builder *SelectBuilder) WhereNotIn(column string, value interface{}) *SelectBuilder {
+	builder.getWhere().WhereNotIn(column, value)
+	return builder
+}
+
+func (builder *SelectBuilder) WhereNotBetween(column string, min, max interface{}) *SelectBuilder {
+	builder.getWhere().WhereNotBetween(column, min, max)
+	return builder
+}
+
+func (builder *SelectBuilder) WhereNotLike(column string, value interface{}) *SelectBuilder {
+	builder.getWhere().WhereNotLike(column, value)
+	return builder
+}
+
+// WhereNot where not (...)
+func (builder *SelectBuilder) WhereNot(f BuilderFunc) *SelectBuilder {
+	builder.getWhere().WhereNot(f)
+	return builder
+}
+
+func (builder *SelectBuilder) OrWhereNotIn(column string, value interface{}) *SelectBuilder {
+	builder.getWhere().OrWhereNotIn(column, value)
+	return builder
+}
+
+func (builder *SelectBuilder) OrWhereNotBetween(column string, min, max interface{}) *SelectBuilder {
+	builder.getWhere().OrWhereNotBetween(column, min, max)
+	return builder
+}
+
+func (builder *SelectBuilder) OrWhereNotLike(column string, value interface{}) *SelectBuilder {
+	builder.getWhere().OrWhereNotLike(column, value)
+	return builder
+}
+
+func (builder *SelectBuilder) OrWhereNot(f BuilderFunc) *SelectBuilder {
+	builder.getWhere().OrWhereNot(f)
+	return builder
+}
+
+// OrderBy order by column sort，column为字段名或Raw表达式，错误的排序规则将被忽略
+// 字段名可能来自用户输入，始终会被校验，表达式请使用Raw，如 OrderBy(Raw("field(id, 3, 1, 2)"), "asc")
+func (builder *SelectBuilder) OrderBy(column interface{}, sort string) *SelectBuilder {
+	// 忽略错误的排序规则
+	if order := newOrderStat(column, sort); order != nil {
+		builder.order = append(builder.order, order)
 	}
-	builder.order = append(builder.order, column+" "+sort)
 	return builder
 }
 
@@ -179,41 +492,14 @@ func (builder *SelectBuilder) Limit(offset, limit int) *SelectBuilder {
 	return builder
 }
 
+// String 返回参数插值后的sql，构建过程中有错误时返回空字符串
 func (builder *SelectBuilder) String() string {
-	sql, data := builder.Build()
-	index := 0
-	newSql := make([]rune, 0, len(data))
-	getData := func(data []interface{}, index int) string {
-		if index > (len(data) - 1) {
-			return ""
-		}
-
-		datum := data[index]
-
-		v := reflect.ValueOf(datum)
-		switch v.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return strconv.Itoa(int(v.Int()))
-		case reflect.String:
-			return "\"" + strings.ReplaceAll(strings.ReplaceAll(v.String(), "\\", "\\\\"), "\"", "\\\"") + "\""
-		case reflect.Bool:
-			return "1"
-		default:
-			return ""
-		}
-	}
-
-	for _, sqlRune := range sql {
-		if sqlRune == rune('?') {
-			// 将rune替换成 值
-			repData := getData(data, index)
-			newSql = append(newSql, []rune(repData)...)
-			index++
-		} else {
-			newSql = append(newSql, sqlRune)
-		}
+	c := newBuildContext(builder.dialect, builder.quote)
+	sql, data := builder.build(c)
+	if c.err() != nil {
+		return ""
 	}
-	return string(newSql)
+	return interpolate(sql, data)
 }
 
 func (builder *SelectBuilder) LeftJoin(table string, on WhereInterface) *SelectBuilder {
@@ -0,0 +1,89 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SQLiteOptions sqlite方言配置
+type SQLiteOptions struct {
+	// StrictLock sqlite不支持行锁，为true时构建带锁的语句将返回ErrUnsupportedLock，否则忽略锁
+	StrictLock bool
+	// MaxPlaceholders 单条语句允许的最大参数数量，默认为999，sqlite 3.32.0及之后的版本为32766
+	MaxPlaceholders int
+}
+
+type sqliteDialect struct {
+	strictLock      bool
+	maxPlaceholders int
+}
+
+// NewSQLiteDialect 创建sqlite方言
+// sqlite不支持行锁，strictLock为true时构建带锁的语句将返回ErrUnsupportedLock，否则忽略锁
+func NewSQLiteDialect(strictLock bool) Dialect {
+	return NewSQLiteDialectWithOptions(SQLiteOptions{StrictLock: strictLock})
+}
+
+// NewSQLiteDialectWithOptions 按配置创建sqlite方言，可以指定参数数量限制
+func NewSQLiteDialectWithOptions(opts SQLiteOptions) Dialect {
+	if opts.MaxPlaceholders <= 0 {
+		opts.MaxPlaceholders = 999
+	}
+	return &sqliteDialect{
+		strictLock:      opts.StrictLock,
+		maxPlaceholders: opts.MaxPlaceholders,
+	}
+}
+
+func (d *sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+func (d *sqliteDialect) Placeholder(n int) string {
+	return "?"
+}
+
+func (d *sqliteDialect) Paginate(offset, limit int, ordered bool) (string, string, error) {
+	if limit <= 0 {
+		// sqlite的offset必须搭配limit使用，-1表示不限制
+		limit = -1
+	}
+	sql := fmt.Sprintf("limit %d", limit)
+	if offset > 0 {
+		sql = fmt.Sprintf("%s offset %d", sql, offset)
+	}
+	return "", sql, nil
+}
+
+func (d *sqliteDialect) Lock(locker Locker) (string, string, error) {
+	if d.strictLock {
+		return "", "", ErrUnsupportedLock
+	}
+	return "", "", nil
+}
+
+func (d *sqliteDialect) Insert(mode InsertMode) (string, error) {
+	switch mode {
+	case InsertIgnore:
+		return "insert or ignore into", nil
+	case InsertReplace:
+		return "insert or replace into", nil
+	}
+	return "insert into", nil
+}
+
+func (d *sqliteDialect) MaxPlaceholders() int {
+	return d.maxPlaceholders
+}
+
+func (d *sqliteDialect) Supports(feature Feature) bool {
+	switch feature {
+	case FeatureReturning, FeatureOnConflict, FeatureUpdateFrom:
+		return true
+	}
+	return false
+}
+
+func (d *sqliteDialect) Quote(ident string) string {
+	return "\"" + strings.ReplaceAll(ident, "\"", "\"\"") + "\""
+}
@@ -0,0 +1,66 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type sqlserverDialect struct{}
+
+func (d *sqlserverDialect) Name() string {
+	return "sqlserver"
+}
+
+func (d *sqlserverDialect) Placeholder(n int) string {
+	return "@p" + strconv.Itoa(n)
+}
+
+// Paginate 只有limit时使用top n，有offset时使用offset ... fetch next，此时必须包含order by
+func (d *sqlserverDialect) Paginate(offset, limit int, ordered bool) (string, string, error) {
+	if offset == 0 {
+		return fmt.Sprintf("top %d", limit), "", nil
+	}
+	if !ordered {
+		return "", "", ErrOffsetWithoutOrder
+	}
+	sql := fmt.Sprintf("offset %d rows", offset)
+	if limit > 0 {
+		sql = fmt.Sprintf("%s fetch next %d rows only", sql, limit)
+	}
+	return "", sql, nil
+}
+
+// Lock sqlserver通过表提示加锁
+func (d *sqlserverDialect) Lock(locker Locker) (string, string, error) {
+	switch locker.(type) {
+	case *UpdateLocker:
+		return "with (updlock, rowlock)", "", nil
+	case *ShareLocker:
+		return "with (holdlock, rowlock)", "", nil
+	}
+	return "", "", ErrUnsupportedLock
+}
+
+func (d *sqlserverDialect) Insert(mode InsertMode) (string, error) {
+	if mode != InsertDefault {
+		return "", unsupported(d, mode)
+	}
+	return "insert into", nil
+}
+
+func (d *sqlserverDialect) MaxPlaceholders() int {
+	return 2100
+}
+
+func (d *sqlserverDialect) Supports(feature Feature) bool {
+	switch feature {
+	case FeatureOutput, FeatureAnyAll, FeatureDefaultValue:
+		return true
+	}
+	return false
+}
+
+func (d *sqlserverDialect) Quote(ident string) string {
+	return "[" + strings.ReplaceAll(ident, "]", "]]") + "]"
+}
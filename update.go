@@ -1,9 +1,10 @@
 package sqlbuilder
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"reflect"
-	"strconv"
 	"strings"
 )
 
@@ -12,29 +13,206 @@ type UpdateBuilder struct {
 	sql       string
 	table     string
 	where     *WhereBuilder
-	fieldData map[string]interface{}
+	join      []*Join
+	order     []*orderStat
+	limit     int
+	allRows   bool
+	dialect   Dialect
+	quote     *bool
+	err       error
+	returning []string
+	sets      []*setStat
+	chunkSize int
 	data      []interface{}
 }
 
+// columnExpr 基于字段自身的运算表达式，如 age + ?
+type columnExpr struct {
+	column  string
+	operate string
+	value   interface{}
+}
+
+func (expr *columnExpr) Build() (string, []interface{}) {
+	return expr.build(newBuildContext(nil, nil))
+}
+
+func (expr *columnExpr) build(c *buildContext) (string, []interface{}) {
+	return fmt.Sprintf("%s %s ?", c.ident(expr.column), expr.operate), []interface{}{expr.value}
+}
+
+// caseExpr 按关联字段的值批量更新，如 case id when ? then ? ... else column end
+type caseExpr struct {
+	column string
+	key    string
+	cases  map[interface{}]interface{}
+	err    error
+}
+
+func (expr *caseExpr) Build() (string, []interface{}) {
+	return expr.build(newBuildContext(nil, nil))
+}
+
+func (expr *caseExpr) build(c *buildContext) (string, []interface{}) {
+	c.addError(expr.err)
+	keys := expr.keys()
+	data := make([]interface{}, 0, len(keys)*2)
+	whens := make([]string, len(keys))
+	for i, key := range keys {
+		value := expr.cases[key]
+		if t, ok := value.(Builder); ok {
+			then, thenData := buildWith(t, c)
+			whens[i] = "when ? then " + then
+			data = append(data, key)
+			data = append(data, thenData...)
+			continue
+		}
+		whens[i] = "when ? then ?"
+		data = append(data, key, value)
+	}
+	return fmt.Sprintf("case %s %s else %s end", c.ident(expr.key), strings.Join(whens, " "), c.ident(expr.column)), data
+}
+
+// keys 按值排序的关联字段的值
+func (expr *caseExpr) keys() []interface{} {
+	keys := make([]interface{}, 0, len(expr.cases))
+	for key := range expr.cases {
+		keys = append(keys, key)
+	}
+	sortValues(keys)
+	return keys
+}
+
+// filter 只保留指定的关联字段值，没有剩余时返回nil
+func (expr *caseExpr) filter(keys map[interface{}]bool) *caseExpr {
+	cases := make(map[interface{}]interface{})
+	for key, value := range expr.cases {
+		if keys[key] {
+			cases[key] = value
+		}
+	}
+	if len(cases) == 0 && expr.err == nil {
+		return nil
+	}
+	return &caseExpr{column: expr.column, key: expr.key, cases: cases, err: expr.err}
+}
+
 func Update(table string) *UpdateBuilder {
 	return &UpdateBuilder{
-		table:     table,
-		fieldData: make(map[string]interface{}),
+		table: table,
 	}
 }
 
+// Set set column = value，按调用顺序构建，重复设置同一字段时覆盖之前的值
 func (builder *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
-	builder.fieldData[column] = value
+	for _, set := range builder.sets {
+		if set.column == column {
+			set.value = value
+			return builder
+		}
+	}
+	builder.sets = append(builder.sets, &setStat{
+		column: column,
+		value:  value,
+	})
+	return builder
+}
+
+// SetCase 按关联字段批量更新多行，values为 关联字段的值 => 更新的值 的map
+// 构建为 set column = case key when ? then ? ... else column end where key in (...)，
+// 多次调用可以同时更新多个字段，但必须使用同一个关联字段
+func (builder *UpdateBuilder) SetCase(column, keyColumn string, values interface{}) *UpdateBuilder {
+	expr := &caseExpr{
+		column: column,
+		key:    keyColumn,
+		cases:  make(map[interface{}]interface{}),
+	}
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Map {
+		expr.err = fmt.Errorf("%w: SetCase expects a map, got %T", ErrInvalidValue, values)
+	} else {
+		iter := v.MapRange()
+		for iter.Next() {
+			expr.cases[iter.Key().Interface()] = iter.Value().Interface()
+		}
+	}
+	return builder.Set(column, expr)
+}
+
+// ChunkSize SetCase批量更新时，每条语句最多更新的关联字段值数量，用于Statements和ExecContext拆分语句
+// 未指定时仅在超出方言的占位符数量限制时拆分
+func (builder *UpdateBuilder) ChunkSize(size int) *UpdateBuilder {
+	builder.chunkSize = size
 	return builder
 }
 
+// Map 批量设置字段，按字段名排序构建
 func (builder *UpdateBuilder) Map(mapData map[string]interface{}) *UpdateBuilder {
-	for column, value := range mapData {
-		builder.Set(column, value)
+	for _, column := range sortedKeys(mapData) {
+		builder.Set(column, mapData[column])
+	}
+	return builder
+}
+
+// Dialect 指定构建时使用的方言，未指定时使用全局默认方言
+func (builder *UpdateBuilder) Dialect(d Dialect) *UpdateBuilder {
+	builder.dialect = d
+	return builder
+}
+
+// QuoteIdentifier 指定是否引用表名、字段名等标识符，未指定时使用全局设置
+func (builder *UpdateBuilder) QuoteIdentifier(quote bool) *UpdateBuilder {
+	builder.quote = &quote
+	return builder
+}
+
+// Returning update ... returning columns，返回更新后的行，sqlserver构建为output inserted.column
+func (builder *UpdateBuilder) Returning(columns ...string) *UpdateBuilder {
+	builder.returning = append(builder.returning, columns...)
+	return builder
+}
+
+// InnerJoin update a inner join b on ... set ...
+// mysql构建为update join，postgres、sqlite构建为update ... from b where ...
+func (builder *UpdateBuilder) InnerJoin(table string, on WhereInterface) *UpdateBuilder {
+	builder.join = append(builder.join, &Join{
+		link:  "inner",
+		table: table,
+		on:    on,
+	})
+	return builder
+}
+
+// LeftJoin update a left join b on ... set ...，仅mysql支持
+func (builder *UpdateBuilder) LeftJoin(table string, on WhereInterface) *UpdateBuilder {
+	builder.join = append(builder.join, &Join{
+		link:  "left",
+		table: table,
+		on:    on,
+	})
+	return builder
+}
+
+// AllRows 允许在没有where条件时更新所有行，否则安全模式下将返回ErrUnfiltered
+func (builder *UpdateBuilder) AllRows() *UpdateBuilder {
+	builder.allRows = true
+	return builder
+}
+
+// OrderBy update ... order by column sort，仅mysql支持，column为字段名或Raw表达式，错误的排序规则将被忽略
+func (builder *UpdateBuilder) OrderBy(column interface{}, sort string) *UpdateBuilder {
+	if order := newOrderStat(column, sort); order != nil {
+		builder.order = append(builder.order, order)
 	}
 	return builder
 }
 
+// Limit update ... limit n，仅mysql支持
+func (builder *UpdateBuilder) Limit(limit int) *UpdateBuilder {
+	builder.limit = limit
+	return builder
+}
+
 func (builder *UpdateBuilder) getWhere() WhereInterface {
 	if builder.where == nil {
 		builder.where = &WhereBuilder{}
@@ -101,75 +279,458 @@ func (builder *UpdateBuilder) OrWhereFunc(f BuilderFunc) *UpdateBuilder {
 	return builder
 }
 
+func (builder *UpdateBuilder) WhereColumn(column1, column2 string) *UpdateBuilder {
+	builder.getWhere().WhereColumn(column1, column2)
+	return builder
+}
+
+func (builder *UpdateBuilder) WhereColumnOperate(column1, operate, column2 string) *UpdateBuilder {
+	builder.getWhere().WhereColumnOperate(column1, operate, column2)
+	return builder
+}
+
+func (builder *UpdateBuilder) OrWhereColumn(column1, column2 string) *UpdateBuilder {
+	builder.getWhere().OrWhereColumn(column1, column2)
+	return builder
+}
+
+func (builder *UpdateBuilder) OrWhereColumnOperate(column1, operate, column2 string) *UpdateBuilder {
+	builder.getWhere().OrWhereColumnOperate(column1, operate, column2)
+	return builder
+}
+
+func (builder *UpdateBuilder) OrWhereIn(column string, value interface{}) *UpdateBuilder {
+	builder.getWhere().OrWhereIn(column, value)
+	return builder
+}
+
+func (builder *UpdateBuilder) OrWhereBetween(column string, min, max interface{}) *UpdateBuilder {
+	builder.getWhere().OrWhereBetween(column, min, max)
+	return builder
+}
+
+func (builder *UpdateBuilder) OrWhereLike(column string, value interface{}) *UpdateBuilder {
+	builder.getWhere().OrWhereLike(column, value)
+	return builder
+}
+
+func (builder *UpdateBuilder) OrWhereNull(column string) *UpdateBuilder {
+	builder.getWhere().OrWhereNull(column)
+	return builder
+}
+
+func (builder *UpdateBuilder) OrWhereNotNull(column string) *UpdateBuilder {
+	builder.getWhere().OrWhereNotNull(column)
+	return builder
+}
+
+// WhereExists where exists (select ...)
+func (builder *UpdateBuilder) WhereExists(f BuilderFunc) *UpdateBuilder {
+	builder.getWhere().WhereExists(f)
+	return builder
+}
+
+func (builder *UpdateBuilder) WhereNotExists(f BuilderFunc) *UpdateBuilder {
+	builder.getWhere().WhereNotExists(f)
+	return builder
+}
+
+func (builder *UpdateBuilder) OrWhereExists(f BuilderFunc) *UpdateBuilder {
+	builder.getWhere().OrWhereExists(f)
+	return builder
+}
+
+func (builder *UpdateBuilder) OrWhereNotExists(f BuilderFunc) *UpdateBuilder {
+	builder.getWhere().OrWhereNotExists(f)
+	return builder
+}
+
+// WhereAny where column > any (select ...)
+func (builder *UpdateBuilder) WhereAny(column, operate string, value interface{}) *UpdateBuilder {
+	builder.getWhere().WhereAny(column, operate, value)
+	return builder
+}
+
+// WhereAll where column > all (select ...)
+func (builder *UpdateBuilder) WhereAll(column, operate string, value interface{}) *UpdateBuilder {
+	builder.getWhere().WhereAll(column, operate, value)
+	return builder
+}
+
+func (builder *UpdateBuilder) OrWhereAny(column, operate string, value interface{}) *UpdateBuilder {
+	builder.getWhere().OrWhereAny(column, operate, value)
+	return builder
+}
+
+func (builder *UpdateBuilder) OrWhereAll(column, operate string, value interface{}) *UpdateBuilder {
+	builder.getWhere().OrWhereAll(column, operate, value)
+	return builder
+}
+
+func (builder *UpdateBuilder) WhereNotIn(column string, value interface{}) *UpdateBuilder {
+	builder.getWhere().WhereNotIn(column, value)
+	return builder
+}
+
+func (builder *UpdateBuilder) WhereNotBetween(column string, min, max interface{}) *UpdateBuilder {
+	builder.getWhere().WhereNotBetween(column, min, max)
+	return builder
+}
+
+func (builder *UpdateBuilder) WhereNotLike(column string, value interface{}) *UpdateBuilder {
+	builder.getWhere().WhereNotLike(column, value)
+	return builder
+}
+
+// WhereNot where not (...)
+func (builder *UpdateBuilder) WhereNot(f BuilderFunc) *UpdateBuilder {
+	builder.getWhere().WhereNot(f)
+	return builder
+}
+
+func (builder *UpdateBuilder) OrWhereNotIn(column string, value interface{}) *UpdateBuilder {
+	builder.getWhere().OrWhereNotIn(column, value)
+	return builder
+}
+
+func (builder *UpdateBuilder) OrWhereNotBetween(column string, min, max interface{}) *UpdateBuilder {
+	builder.getWhere().OrWhereNotBetween(column, min, max)
+	return builder
+}
+
+func (builder *UpdateBuilder) OrWhereNotLike(column string, value interface{}) *UpdateBuilder {
+	builder.getWhere().OrWhereNotLike(column, value)
+	return builder
+}
+
+func (builder *UpdateBuilder) OrWhereNot(f BuilderFunc) *UpdateBuilder {
+	builder.getWhere().OrWhereNot(f)
+	return builder
+}
+
 func (builder *UpdateBuilder) Increment(column string, value interface{}) *UpdateBuilder {
-	builder.Set(column, Raw(column+" + ?", value))
+	builder.Set(column, &columnExpr{column: column, operate: "+", value: value})
 	return builder
 }
 
 func (builder *UpdateBuilder) Decrement(column string, value interface{}) *UpdateBuilder {
-	builder.Set(column, Raw(column+" - ?", value))
+	builder.Set(column, &columnExpr{column: column, operate: "-", value: value})
 	return builder
 }
 
+// String 返回参数插值后的sql，构建过程中有错误时返回空字符串
 func (builder *UpdateBuilder) String() string {
-	sql, data := builder.Build()
-	index := 0
-	newSql := make([]rune, 0, len(data))
-	getData := func(data []interface{}, index int) string {
-		if index > (len(data) - 1) {
-			return ""
-		}
-
-		datum := data[index]
-
-		v := reflect.ValueOf(datum)
-		switch v.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			return strconv.Itoa(int(v.Int()))
-		case reflect.String:
-			return "\"" + strings.ReplaceAll(strings.ReplaceAll(v.String(), "\\", "\\\\"), "\"", "\\\"") + "\""
-		default:
-			return ""
+	c := newBuildContext(builder.dialect, builder.quote)
+	sql, data := builder.build(c)
+	if c.err() != nil {
+		return ""
+	}
+	return interpolate(sql, data)
+}
+
+// Build 构建sql，构建过程中有错误时返回空sql，错误可以通过BuildE或Err获取
+func (builder *UpdateBuilder) Build() (string, []interface{}) {
+	if err := builder.buildE(); err != nil {
+		return "", nil
+	}
+	return builder.sql, builder.data
+}
+
+// BuildE 构建sql，构建过程中的错误将被返回
+func (builder *UpdateBuilder) BuildE() (string, []interface{}, error) {
+	if err := builder.buildE(); err != nil {
+		return "", nil, err
+	}
+	return builder.sql, builder.data, nil
+}
+
+// Err 返回构建sql时的错误
+func (builder *UpdateBuilder) Err() error {
+	return builder.buildE()
+}
+
+// buildFor 按执行器的方言构建sql
+func (builder *UpdateBuilder) buildFor(exec Executor) (string, []interface{}, error) {
+	c := newBuildContext(executorDialect(builder.dialect, exec), builder.quote)
+	sql, data := builder.build(c)
+	return rebind(c.dialect, sql), data, c.err()
+}
+
+// Statements 构建更新语句，SetCase批量更新时将按ChunkSize或方言的占位符数量限制拆分为多条语句
+func (builder *UpdateBuilder) Statements() ([]*Statement, error) {
+	c := newBuildContext(builder.dialect, builder.quote)
+	statements := builder.statements(c)
+	if err := c.err(); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+// ExecContext 构建并执行sql
+// SetCase批量更新时可能拆分为多条语句依次执行，需要保证原子性时请使用*sql.Tx执行
+func (builder *UpdateBuilder) ExecContext(ctx context.Context, exec Executor) (sql.Result, error) {
+	c := newBuildContext(executorDialect(builder.dialect, exec), builder.quote)
+	statements := builder.statements(c)
+	if err := c.err(); err != nil {
+		return nil, err
+	}
+	return execStatements(ctx, exec, statements)
+}
+
+// QueryContext 构建并执行查询
+func (builder *UpdateBuilder) QueryContext(ctx context.Context, exec Executor) (*sql.Rows, error) {
+	query, args, err := builder.buildFor(exec)
+	return queryContext(ctx, exec, query, args, err)
+}
+
+// QueryRowContext 构建并执行查询，返回一行结果
+func (builder *UpdateBuilder) QueryRowContext(ctx context.Context, exec Executor) *Row {
+	query, args, err := builder.buildFor(exec)
+	return queryRowContext(ctx, exec, query, args, err)
+}
+
+// ScanStruct 执行并将Returning返回的第一行扫描到结构体中，dst必须为结构体指针
+func (builder *UpdateBuilder) ScanStruct(ctx context.Context, exec Executor, dst interface{}) error {
+	v, err := structDest(dst)
+	if err != nil {
+		return err
+	}
+	rows, err := builder.QueryContext(ctx, exec)
+	if err != nil {
+		return err
+	}
+	return scanOne(rows, v, defaultUnknownColumnPolicy)
+}
+
+// ScanStructs 执行并将Returning返回的所有行扫描到切片中，dst必须为 *[]T 或 *[]*T
+func (builder *UpdateBuilder) ScanStructs(ctx context.Context, exec Executor, dst interface{}) error {
+	v, err := sliceDest(dst)
+	if err != nil {
+		return err
+	}
+	rows, err := builder.QueryContext(ctx, exec)
+	if err != nil {
+		return err
+	}
+	return scanAll(rows, v, defaultUnknownColumnPolicy)
+}
+
+func (builder *UpdateBuilder) buildE() error {
+	if !builder.isBuilt {
+		c := newBuildContext(builder.dialect, builder.quote)
+		sql, data := builder.build(c)
+		builder.sql = rebind(c.dialect, sql)
+		builder.data = data
+		builder.err = c.err()
+		builder.isBuilt = true
+	}
+	return builder.err
+}
+
+func (builder *UpdateBuilder) build(c *buildContext) (string, []interface{}) {
+	if builder.table == "" {
+		c.addError(ErrEmptyTable)
+	}
+	if len(builder.sets) == 0 {
+		c.addError(fmt.Errorf("%w: update without set", ErrEmptyValues))
+	}
+	key, keys := builder.caseKeys(c)
+	sql, data := builder.buildKeys(c, key, keys)
+	if max := c.dialect.MaxPlaceholders(); max > 0 && len(data) > max {
+		c.addError(fmt.Errorf("%w: %d placeholders, %s allows %d", ErrTooManyPlaceholders, len(data), c.dialect.Name(), max))
+	}
+	return sql, data
+}
+
+// statements 构建更新语句，SetCase批量更新时按关联字段的值拆分为多条语句
+func (builder *UpdateBuilder) statements(c *buildContext) []*Statement {
+	if builder.table == "" {
+		c.addError(ErrEmptyTable)
+	}
+	if len(builder.sets) == 0 {
+		c.addError(fmt.Errorf("%w: update without set", ErrEmptyValues))
+	}
+	key, keys := builder.caseKeys(c)
+	size := len(keys)
+	if builder.chunkSize > 0 && builder.chunkSize < size {
+		size = builder.chunkSize
+	}
+	if max := c.dialect.MaxPlaceholders(); max > 0 && size > 0 {
+		// 每个关联字段的值占用where in中的一个参数，以及每个case中的两个参数
+		_, data := builder.buildKeys(newBuildContext(c.dialect, &c.quote), key, nil)
+		perKey := 1
+		for _, set := range builder.sets {
+			if _, ok := set.value.(*caseExpr); ok {
+				perKey += 2
+			}
+		}
+		if n := (max - len(data)) / perKey; n < size {
+			size = n
+		}
+		if size < 1 {
+			size = 1
 		}
 	}
+	if size == 0 {
+		sql, data := builder.buildKeys(c, key, keys)
+		return []*Statement{{SQL: rebind(c.dialect, sql), Args: data}}
+	}
+	statements := make([]*Statement, 0, len(keys)/size+1)
+	for start := 0; start < len(keys); start += size {
+		end := start + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		sql, data := builder.buildKeys(c, key, keys[start:end])
+		statements = append(statements, &Statement{
+			SQL:  rebind(c.dialect, sql),
+			Args: data,
+		})
+	}
+	return statements
+}
 
-	for _, sqlRune := range sql {
-		if sqlRune == rune('?') {
-			// 将rune替换成 值
-			repData := getData(data, index)
-			newSql = append(newSql, []rune(repData)...)
-			index++
-		} else {
-			newSql = append(newSql, sqlRune)
+// buildOrderLimit 构建update、delete语句的order by和limit，多表语句不支持
+func buildOrderLimit(c *buildContext, order []*orderStat, limit int, joined bool) (string, []interface{}) {
+	if len(order) == 0 && limit <= 0 {
+		return "", nil
+	}
+	if !c.dialect.Supports(FeatureWriteLimit) {
+		c.addError(unsupported(c.dialect, FeatureWriteLimit))
+	} else if joined {
+		c.addError(unsupported(c.dialect, FeatureWriteLimit.String()+" with join"))
+	}
+	sql := ""
+	var data []interface{}
+	if len(order) > 0 {
+		var orderSql string
+		orderSql, data = buildOrder(c, order)
+		sql = "order by " + orderSql
+	}
+	if limit > 0 {
+		sql = strings.TrimSpace(fmt.Sprintf("%s limit %d", sql, limit))
+	}
+	return sql, data
+}
+
+// caseKeys SetCase的关联字段及所有的值，值按顺序排列
+func (builder *UpdateBuilder) caseKeys(c *buildContext) (string, []interface{}) {
+	var key string
+	keys := make([]interface{}, 0)
+	seen := make(map[interface{}]bool)
+	for _, set := range builder.sets {
+		expr, ok := set.value.(*caseExpr)
+		if !ok {
+			continue
+		}
+		if key == "" {
+			key = expr.key
+		} else if expr.key != key {
+			c.addError(fmt.Errorf("%w: SetCase with different key columns %s and %s", ErrInvalidValue, key, expr.key))
+		}
+		for k := range expr.cases {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
 		}
 	}
-	return string(newSql)
+	if key != "" && len(keys) == 0 {
+		c.addError(fmt.Errorf("%w: SetCase without values", ErrEmptyValues))
+	}
+	sortValues(keys)
+	return key, keys
 }
 
-func (builder *UpdateBuilder) Build() (string, []interface{}) {
-	if !builder.isBuilt {
-		fields := make([]string, 0, len(builder.fieldData))
-		for k, v := range builder.fieldData {
-			if t, ok := v.(Builder); ok {
-				c, d := t.Build()
-				fields = append(fields, k+" = "+c)
-				builder.data = append(builder.data, d...)
-			} else {
-				fields = append(fields, k+" = ?")
-				builder.data = append(builder.data, v)
+// buildKeys 构建更新语句，key不为空时只更新关联字段为keys的行
+func (builder *UpdateBuilder) buildKeys(c *buildContext, key string, keys []interface{}) (string, []interface{}) {
+	sql := fmt.Sprintf("update %s", c.ident(builder.table))
+	data := make([]interface{}, 0)
+	from := ""
+	conditions := make([]string, 0)
+	var conditionsData []interface{}
+	if len(builder.join) > 0 {
+		switch {
+		case c.dialect.Supports(FeatureUpdateJoin):
+			for _, j := range builder.join {
+				join, joinData := j.build(c)
+				sql = fmt.Sprintf("%s %s", sql, join)
+				data = append(data, joinData...)
 			}
+		case c.dialect.Supports(FeatureUpdateFrom):
+			// 关联表放在from中，关联条件并入where
+			tables := make([]string, len(builder.join))
+			for i, j := range builder.join {
+				if j.link != "inner" {
+					c.addError(unsupported(c.dialect, FeatureUpdateFrom.String()+" with "+j.link+" join"))
+				}
+				tables[i] = c.ident(j.table)
+				on, onData := buildWith(j.on, c)
+				conditions = append(conditions, on)
+				conditionsData = append(conditionsData, onData...)
+			}
+			from = "from " + strings.Join(tables, ", ")
+		default:
+			c.addError(unsupported(c.dialect, FeatureUpdateJoin))
 		}
+	}
 
-		sql := fmt.Sprintf("update %s set %s", builder.table, strings.Join(fields, ", "))
-		if builder.where != nil {
-			where, whereData := builder.where.Build()
-			if where != "" {
-				sql = fmt.Sprintf("%s where %s", sql, where)
-				builder.data = append(builder.data, whereData...)
+	sets := builder.sets
+	if key != "" {
+		only := make(map[interface{}]bool, len(keys))
+		for _, k := range keys {
+			only[k] = true
+		}
+		sets = make([]*setStat, 0, len(builder.sets))
+		for _, set := range builder.sets {
+			if expr, ok := set.value.(*caseExpr); ok {
+				if expr = expr.filter(only); expr == nil {
+					continue
+				}
+				set = &setStat{column: set.column, value: expr}
 			}
+			sets = append(sets, set)
 		}
-		builder.sql = sql
-		builder.isBuilt = true
+		replace := make([]string, len(keys))
+		for i := range keys {
+			replace[i] = "?"
+		}
+		conditions = append(conditions, fmt.Sprintf("%s in (%s)", c.ident(key), strings.Join(replace, ", ")))
+		conditionsData = append(conditionsData, keys...)
 	}
-	return builder.sql, builder.data
+	setsSql, setsData := buildSets(c, sets, "")
+	sql = fmt.Sprintf("%s set %s", sql, setsSql)
+	data = append(data, setsData...)
+
+	output, returning := buildReturning(c, builder.returning, "inserted")
+	if output != "" {
+		sql = fmt.Sprintf("%s %s", sql, output)
+	}
+	if from != "" {
+		sql = fmt.Sprintf("%s %s", sql, from)
+	}
+	filtered := key != ""
+	if builder.where != nil {
+		where, whereData := builder.where.build(c)
+		if where != "" {
+			filtered = true
+			conditions = append(conditions, where)
+			conditionsData = append(conditionsData, whereData...)
+		}
+	}
+	if !filtered && defaultSafeMode && !builder.allRows {
+		c.addError(ErrUnfiltered)
+	}
+	if len(conditions) > 0 {
+		sql = fmt.Sprintf("%s where %s", sql, andConditions(conditions))
+		data = append(data, conditionsData...)
+	}
+	if orderLimit, orderData := buildOrderLimit(c, builder.order, builder.limit, len(builder.join) > 0); orderLimit != "" {
+		sql = fmt.Sprintf("%s %s", sql, orderLimit)
+		data = append(data, orderData...)
+	}
+	if returning != "" {
+		sql = fmt.Sprintf("%s %s", sql, returning)
+	}
+	return sql, data
 }
@@ -1,6 +1,8 @@
 package sqlbuilder_test
 
 import (
+	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/sureyee/sqlbuilder"
@@ -8,45 +10,39 @@ import (
 
 func TestUpdate(t *testing.T) {
 	sql := "update users set username = \"zhangsan\""
-	builderSql := sqlbuilder.Update("users").Set("username", "zhangsan").String()
+	builderSql := sqlbuilder.Update("users").Set("username", "zhangsan").AllRows().String()
 	if sql != builderSql {
 		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
 	}
 }
 
 func TestUpdateColumns(t *testing.T) {
-	sql1 := "update users set username = \"zhangsan\", age = 10"
-	sql2 := "update users set age = 10, username = \"zhangsan\""
-	builderSql := sqlbuilder.Update("users").Set("username", "zhangsan").Set("age", 10).String()
-
-	if sql1 == builderSql {
-		return
+	sql := "update users set username = \"zhangsan\", age = 10"
+	builderSql := sqlbuilder.Update("users").Set("username", "zhangsan").Set("age", 10).AllRows().String()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
 	}
 
-	if sql2 == builderSql {
-		return
+	// 重复设置的字段保持第一次设置的位置
+	sql = "update users set username = \"lisi\", age = 10"
+	builderSql = sqlbuilder.Update("users").Set("username", "zhangsan").Set("age", 10).Set("username", "lisi").AllRows().String()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
 	}
-
-	t.Errorf("expected:`%v` or `%v`, got:`%v`", sql1, sql2, builderSql)
 }
 
 func TestUpateMap(t *testing.T) {
-	sql1 := "update users set username = \"zhangsan\", age = 10"
-	sql2 := "update users set age = 10, username = \"zhangsan\""
-	builderSql := sqlbuilder.Update("users").Map(map[string]interface{}{
-		"username": "zhangsan",
-		"age":      10,
-	}).String()
-
-	if sql1 == builderSql {
-		return
+	sql := "update users set age = 10, status = 1, username = \"zhangsan\""
+	for i := 0; i < 10; i++ {
+		builderSql := sqlbuilder.Update("users").Map(map[string]interface{}{
+			"username": "zhangsan",
+			"status":   1,
+			"age":      10,
+		}).AllRows().String()
+		if sql != builderSql {
+			t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+		}
 	}
-
-	if sql2 == builderSql {
-		return
-	}
-
-	t.Errorf("expected:`%v` or `%v`, got:`%v`", sql1, sql2, builderSql)
 }
 
 func TestUpdateWhere(t *testing.T) {
@@ -88,3 +84,209 @@ func TestUpdateBuilder_Decrement(t *testing.T) {
 		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
 	}
 }
+
+func TestUpdateReturning(t *testing.T) {
+	sql := "update users set status = $1 where id = $2 returning id, status"
+	builderSql, _ := sqlbuilder.Update("users").Set("status", 1).Where("id", 10).
+		Returning("id", "status").
+		Dialect(sqlbuilder.PostgreSQL).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	sql = "update [users] set [status] = @p1 output [inserted].* where [id] = @p2"
+	builderSql, _ = sqlbuilder.Update("users").Set("status", 1).Where("id", 10).
+		Returning("*").
+		Dialect(sqlbuilder.SQLServer).
+		QuoteIdentifier(true).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	err := sqlbuilder.Update("users").Set("status", 1).Returning("id").Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+	}
+}
+
+func TestUpdateSetCase(t *testing.T) {
+	sql := "update users set status = case id when ? then ? when ? then ? when ? then ? else status end, " +
+		"score = case id when ? then ? else score end, updated_at = ? where (id in (?, ?, ?)) and (deleted = ?)"
+	builderSql, builderData := sqlbuilder.Update("users").
+		SetCase("status", "id", map[int]int{3: 1, 1: 2, 2: 0}).
+		SetCase("score", "id", map[int]int{2: 100}).
+		Set("updated_at", "2020-01-01").
+		Where("deleted", 0).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	expected := []interface{}{1, 2, 2, 0, 3, 1, 2, 100, "2020-01-01", 1, 2, 3, 0}
+	if !reflect.DeepEqual(builderData, expected) {
+		t.Errorf("expected:`%v`, got:`%v`", expected, builderData)
+	}
+
+	err := sqlbuilder.Update("users").SetCase("status", "id", map[int]int{1: 1}).SetCase("score", "uid", map[int]int{1: 1}).Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+
+	err = sqlbuilder.Update("users").SetCase("status", "id", []int{1}).Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+}
+
+func TestUpdateSetCaseStatements(t *testing.T) {
+	statements, err := sqlbuilder.Update("users").
+		SetCase("status", "id", map[int]int{1: 1, 2: 2, 3: 3}).
+		SetCase("score", "id", map[int]int{3: 30}).
+		ChunkSize(2).
+		Dialect(sqlbuilder.PostgreSQL).
+		Statements()
+	if err != nil {
+		t.Fatalf("Statements error: %v", err)
+	}
+	expected := []*sqlbuilder.Statement{
+		{
+			SQL:  "update users set status = case id when $1 then $2 when $3 then $4 else status end where id in ($5, $6)",
+			Args: []interface{}{1, 1, 2, 2, 1, 2},
+		},
+		{
+			SQL:  "update users set status = case id when $1 then $2 else status end, score = case id when $3 then $4 else score end where id in ($5)",
+			Args: []interface{}{3, 3, 3, 30, 3},
+		},
+	}
+	if !reflect.DeepEqual(statements, expected) {
+		for _, statement := range statements {
+			t.Errorf("unexpected statement: %v %v", statement.SQL, statement.Args)
+		}
+	}
+
+	// 超出占位符数量限制时自动拆分，每个id占用3个参数，where中的参数每条语句都会重复
+	statements, err = sqlbuilder.Update("users").
+		SetCase("status", "id", map[int]int{1: 1, 2: 2, 3: 3}).
+		Where("deleted", 0).
+		Dialect(&limitedDialect{Dialect: sqlbuilder.MySQL, max: 7}).
+		Statements()
+	if err != nil {
+		t.Fatalf("Statements error: %v", err)
+	}
+	if len(statements) != 2 || len(statements[0].Args) != 7 || len(statements[1].Args) != 4 {
+		for _, statement := range statements {
+			t.Errorf("unexpected statement: %v %v", statement.SQL, statement.Args)
+		}
+	}
+}
+
+func TestUpdateJoin(t *testing.T) {
+	sql := "update users inner join orders on orders.user_id = users.id and orders.status = ? set users.level = ? where users.level < ?"
+	builderSql, builderData := sqlbuilder.Update("users").
+		InnerJoin("orders", sqlbuilder.WhereColumn("orders.user_id", "users.id").Where("orders.status", 1)).
+		Set("users.level", 2).
+		WhereOperate("users.level", "<", 2).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if !reflect.DeepEqual(builderData, []interface{}{1, 2, 2}) {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+}
+
+func TestUpdateFrom(t *testing.T) {
+	sql := "update users set level = orders.level from orders where (orders.user_id = users.id and orders.status = $1) and (users.level < $2 or users.level is null)"
+	builderSql, builderData := sqlbuilder.Update("users").
+		InnerJoin("orders", sqlbuilder.WhereColumn("orders.user_id", "users.id").Where("orders.status", 1)).
+		Set("level", sqlbuilder.Raw("orders.level")).
+		WhereOperate("users.level", "<", 2).
+		OrWhereFunc(func() sqlbuilder.Builder {
+			return sqlbuilder.WhereNull("users.level")
+		}).
+		Dialect(sqlbuilder.PostgreSQL).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if !reflect.DeepEqual(builderData, []interface{}{1, 2}) {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+
+	// Raw中的OR同样不能越过关联条件
+	sql = "update t set x = $1 from u where (t.id = u.id) and (a = 1 OR b = 2)"
+	builderSql, _ = sqlbuilder.Update("t").
+		Set("x", 1).
+		InnerJoin("u", sqlbuilder.WhereColumn("t.id", "u.id")).
+		WhereFunc(func() sqlbuilder.Builder {
+			return sqlbuilder.Raw("a = 1 OR b = 2")
+		}).
+		Dialect(sqlbuilder.PostgreSQL).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	err := sqlbuilder.Update("users").
+		LeftJoin("orders", sqlbuilder.WhereColumn("orders.user_id", "users.id")).
+		Set("level", 1).
+		Dialect(sqlbuilder.PostgreSQL).
+		Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+	}
+
+	err = sqlbuilder.Update("users").
+		InnerJoin("orders", sqlbuilder.WhereColumn("orders.user_id", "users.id")).
+		Set("level", 1).
+		Dialect(sqlbuilder.SQLServer).
+		Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+	}
+}
+
+func TestUpdateOrderLimit(t *testing.T) {
+	sql := "update users set status = ? where status = ? order by id asc limit 100"
+	builderSql, _ := sqlbuilder.Update("users").Set("status", 1).Where("status", 0).OrderBy("id", "asc").Limit(100).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	err := sqlbuilder.Update("users").Set("status", 1).Limit(100).Dialect(sqlbuilder.PostgreSQL).Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+	}
+}
+
+func TestUpdateSafeMode(t *testing.T) {
+	err := sqlbuilder.Update("users").Set("status", 0).Err()
+	if !errors.Is(err, sqlbuilder.ErrUnfiltered) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnfiltered, err)
+	}
+	if builderSql, builderData := sqlbuilder.Update("users").Set("status", 0).Build(); builderSql != "" || builderData != nil {
+		t.Errorf("expected empty result, got:`%v` %v", builderSql, builderData)
+	}
+	if builderSql := sqlbuilder.Update("users").Set("status", 0).String(); builderSql != "" {
+		t.Errorf("expected:`%v`, got:`%v`", "", builderSql)
+	}
+
+	// 关联更新的关联条件不视为where条件
+	err = sqlbuilder.Update("users").
+		InnerJoin("orders", sqlbuilder.WhereColumn("orders.user_id", "users.id")).
+		Set("users.status", 0).
+		Err()
+	if !errors.Is(err, sqlbuilder.ErrUnfiltered) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnfiltered, err)
+	}
+
+	// SetCase会生成where in条件
+	if err := sqlbuilder.Update("users").SetCase("status", "id", map[int]int{1: 1}).Err(); err != nil {
+		t.Errorf("expected:`%v`, got:`%v`", nil, err)
+	}
+
+	if err := sqlbuilder.Update("users").Set("status", 0).AllRows().Err(); err != nil {
+		t.Errorf("expected:`%v`, got:`%v`", nil, err)
+	}
+}
@@ -1,6 +1,7 @@
 package sqlbuilder
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"reflect"
 	"strings"
@@ -17,18 +18,95 @@ type WhereInterface interface {
 	WhereNotNull(string) WhereInterface
 	WhereOperate(string, string, interface{}) WhereInterface
 	WhereFunc(BuilderFunc) WhereInterface
+	WhereNotIn(string, interface{}) WhereInterface
+	WhereNotBetween(string, interface{}, interface{}) WhereInterface
+	WhereNotLike(string, interface{}) WhereInterface
+	WhereNot(BuilderFunc) WhereInterface
+	WhereExists(BuilderFunc) WhereInterface
+	WhereNotExists(BuilderFunc) WhereInterface
+	WhereAny(string, string, interface{}) WhereInterface
+	WhereAll(string, string, interface{}) WhereInterface
 	OrWhere(string, interface{}) WhereInterface
 	OrWhereOperate(string, string, interface{}) WhereInterface
 	OrWhereFunc(BuilderFunc) WhereInterface
+	OrWhereColumn(string, string) WhereInterface
+	OrWhereColumnOperate(string, string, string) WhereInterface
+	OrWhereBetween(string, interface{}, interface{}) WhereInterface
+	OrWhereLike(string, interface{}) WhereInterface
+	OrWhereIn(string, interface{}) WhereInterface
+	OrWhereNull(string) WhereInterface
+	OrWhereNotNull(string) WhereInterface
+	OrWhereNotIn(string, interface{}) WhereInterface
+	OrWhereNotBetween(string, interface{}, interface{}) WhereInterface
+	OrWhereNotLike(string, interface{}) WhereInterface
+	OrWhereNot(BuilderFunc) WhereInterface
+	OrWhereExists(BuilderFunc) WhereInterface
+	OrWhereNotExists(BuilderFunc) WhereInterface
+	OrWhereAny(string, string, interface{}) WhereInterface
+	OrWhereAll(string, string, interface{}) WhereInterface
 	Build() (string, []interface{})
 }
 
+// WhereBuilder where条件
+// 条件按调用顺序构建，and、or的优先级由数据库决定(and优先)，需要改变优先级时使用WhereFunc分组
 type WhereBuilder struct {
-	wh   []*whereStat
-	orWh []*whereStat
+	conditions []*whereStat
+	dialect    Dialect
+	quote      *bool
 }
 
+// operatorKeywords 关键字操作符允许使用的单词，如 not like、is not、is distinct from、similar to
+var operatorKeywords = map[string]bool{
+	"not":      true,
+	"like":     true,
+	"ilike":    true,
+	"rlike":    true,
+	"regexp":   true,
+	"glob":     true,
+	"is":       true,
+	"distinct": true,
+	"from":     true,
+	"similar":  true,
+	"to":       true,
+}
+
+// validOperator 校验操作符的形式，避免通过操作符注入sql
+// 操作符只能是符号(如 =、<=>、@>、~*)或由关键字组成(如 is not)，不能包含引号、分号、占位符和注释
+func validOperator(operate string) bool {
+	if operate == "" {
+		return false
+	}
+	if strings.Trim(operate, "=<>!~*@&|^%+-/") == "" {
+		return !strings.Contains(operate, "--") && !strings.Contains(operate, "/*") && !strings.Contains(operate, "*/")
+	}
+	for _, word := range strings.Split(strings.ToLower(operate), " ") {
+		if !operatorKeywords[word] {
+			return false
+		}
+	}
+	return true
+}
+
+// comparisons any、all支持的比较操作符
+var comparisons = map[string]bool{
+	"=":  true,
+	"!=": true,
+	"<>": true,
+	"<":  true,
+	"<=": true,
+	">":  true,
+	">=": true,
+}
+
+// quantified column > any (...) 中的比较操作符和值
+type quantified struct {
+	operate string
+	value   interface{}
+}
+
+// whereStat 单个where条件，or表示与前一个条件使用or连接
 type whereStat struct {
+	or      bool
 	column  Column
 	operate string
 	value   interface{}
@@ -70,70 +148,179 @@ func WhereColumnOperate(column1, operate, column2 string) *WhereBuilder {
 	return builder
 }
 
-func (stat *whereStat) Build() (string, []interface{}) {
-	switch stat.operate {
-	case "in":
-		return stat.buildIn()
-	case "between":
-		return stat.buildBetween()
+func (stat *whereStat) build(c *buildContext) (string, []interface{}) {
+	switch strings.ToLower(stat.operate) {
+	case "in", "not in":
+		return stat.buildIn(c)
+	case "between", "not between":
+		return stat.buildBetween(c)
 	case "build":
-		return stat.buildSql()
+		return stat.buildSql(c)
+	case "not build":
+		return stat.buildNotSql(c)
+	case "exists", "not exists":
+		return stat.buildExists(c)
+	case "any", "all":
+		return stat.buildQuantified(c)
 	case "is":
-		return stat.buildIs()
+		return stat.buildIs(c)
 	case "not":
-		return stat.buildNot()
+		return stat.buildNot(c)
 	default:
-		switch f := stat.value.(type) {
-		case func() Builder:
-			sql, data := f().Build()
-			return fmt.Sprintf("%s %s (%s)", stat.column, stat.operate, sql), data
-		case BuilderFunc:
-			sql, data := f().Build()
-			return fmt.Sprintf("%s %s (%s)", stat.column, stat.operate, sql), data
-		case Column:
-			return fmt.Sprintf("%s %s %s", stat.column, stat.operate, stat.value), nil
+		if !validOperator(stat.operate) {
+			c.addError(fmt.Errorf("%w: %q", ErrInvalidOperator, stat.operate))
+			return "", nil
+		}
+		if sql, data, ok := buildSubquery(c, stat.value); ok {
+			return fmt.Sprintf("%s %s (%s)", c.ident(string(stat.column)), stat.operate, sql), data
 		}
-		return fmt.Sprintf("%s %s ?", stat.column, stat.operate), []interface{}{stat.value}
+		if f, ok := stat.value.(Column); ok {
+			return fmt.Sprintf("%s %s %s", c.ident(string(stat.column)), stat.operate, c.ident(string(f))), nil
+		}
+		return fmt.Sprintf("%s %s ?", c.ident(string(stat.column)), stat.operate), []interface{}{stat.value}
 	}
 }
 
-func (stat *whereStat) buildIs() (string, []interface{}) {
-	sql := fmt.Sprintf("%s is null", stat.column)
+// buildSubquery 构建子查询，value不是BuilderFunc时ok为false
+func buildSubquery(c *buildContext, value interface{}) (sql string, data []interface{}, ok bool) {
+	var sub Builder
+	switch f := value.(type) {
+	case func() Builder:
+		if f != nil {
+			sub = f()
+		}
+	case BuilderFunc:
+		if f != nil {
+			sub = f()
+		}
+	default:
+		return "", nil, false
+	}
+	sql, data = buildWith(sub, c)
+	if sql == "" {
+		c.addError(fmt.Errorf("%w: subquery is empty", ErrInvalidValue))
+	}
+	return sql, data, true
+}
+
+func (stat *whereStat) buildIs(c *buildContext) (string, []interface{}) {
+	sql := fmt.Sprintf("%s is null", c.ident(string(stat.column)))
 	return sql, nil
 }
 
-func (stat *whereStat) buildNot() (string, []interface{}) {
-	sql := fmt.Sprintf("%s is not null", stat.column)
+func (stat *whereStat) buildNot(c *buildContext) (string, []interface{}) {
+	sql := fmt.Sprintf("%s is not null", c.ident(string(stat.column)))
 	return sql, nil
 }
 
-func (stat *whereStat) buildSql() (string, []interface{}) {
+func (stat *whereStat) buildSql(c *buildContext) (string, []interface{}) {
 	if v, ok := stat.value.(Builder); ok {
 		if w, ok := v.(*WhereBuilder); ok {
-			sql, data := w.Build()
-			if len(w.wh)+len(w.orWh) > 1 {
+			// 分组中有多个条件时加上括号
+			sql, data, n := w.buildConditions(c)
+			if n > 1 {
 				return "(" + sql + ")", data
 			}
 			return sql, data
 		}
-		return v.Build()
+		return buildWith(v, c)
 	}
 	return "", nil
 }
 
-func (stat *whereStat) buildBetween() (string, []interface{}) {
-	sql := fmt.Sprintf("%s between ? and ?", stat.column)
+// buildNotSql not (...)
+func (stat *whereStat) buildNotSql(c *buildContext) (string, []interface{}) {
+	var sql string
+	var data []interface{}
+	if w, ok := stat.value.(*WhereBuilder); ok {
+		sql, data, _ = w.buildConditions(c)
+	} else if v, ok := stat.value.(Builder); ok {
+		sql, data = buildWith(v, c)
+	}
+	if sql == "" {
+		return "", nil
+	}
+	return "not (" + sql + ")", data
+}
+
+// buildExists exists (select ...)
+func (stat *whereStat) buildExists(c *buildContext) (string, []interface{}) {
+	if sql, data, ok := buildSubquery(c, stat.value); ok {
+		return fmt.Sprintf("%s (%s)", strings.ToLower(stat.operate), sql), data
+	}
+	c.addError(fmt.Errorf("%w: where exists value must be BuilderFunc", ErrInvalidValue))
+	return "", nil
+}
 
-	if v, ok := stat.value.([]interface{}); ok {
+// buildQuantified column > any (select ...)、column = any (?)
+// 值为BuilderFunc时构建为子查询，为driver.Valuer(如pq.Array)时作为数组参数(仅postgres支持)，
+// postgres驱动无法直接编码go切片，因此postgres中不接受切片；
+// 不支持数组参数的方言中值可以为切片，= any 和 <> all 将构建为 in 和 not in
+func (stat *whereStat) buildQuantified(c *buildContext) (string, []interface{}) {
+	q, ok := stat.value.(*quantified)
+	if !ok {
+		c.addError(fmt.Errorf("%w: where %s value must be used with WhereAny or WhereAll", ErrInvalidValue, stat.operate))
+		return "", nil
+	}
+	if !comparisons[q.operate] {
+		c.addError(fmt.Errorf("%w: %q", ErrInvalidOperator, q.operate))
+		return "", nil
+	}
+	column := c.ident(string(stat.column))
+	keyword := strings.ToLower(stat.operate)
+	if sql, data, ok := buildSubquery(c, q.value); ok {
+		if !c.dialect.Supports(FeatureAnyAll) {
+			c.addError(unsupported(c.dialect, FeatureAnyAll))
+		}
+		return fmt.Sprintf("%s %s %s (%s)", column, q.operate, keyword, sql), data
+	}
+
+	_, isValuer := q.value.(driver.Valuer)
+	kind := reflect.ValueOf(q.value).Kind()
+	if !isValuer && kind != reflect.Slice && kind != reflect.Array {
+		c.addError(fmt.Errorf("%w: where %s value must be BuilderFunc, slice or driver.Valuer", ErrInvalidValue, keyword))
+		return "", nil
+	}
+	if c.dialect.Supports(FeatureArray) {
+		if !isValuer {
+			c.addError(fmt.Errorf("%w: where %s array value must be driver.Valuer such as pq.Array, got %T", ErrInvalidValue, keyword, q.value))
+			return "", nil
+		}
+		return fmt.Sprintf("%s %s %s (?)", column, q.operate, keyword), []interface{}{q.value}
+	}
+	if !isValuer {
+		in := &whereStat{column: stat.column, value: q.value}
+		switch {
+		case keyword == "any" && q.operate == "=":
+			in.operate = "in"
+			return in.buildIn(c)
+		case keyword == "all" && (q.operate == "<>" || q.operate == "!="):
+			in.operate = "not in"
+			return in.buildIn(c)
+		}
+	}
+	c.addError(unsupported(c.dialect, FeatureArray))
+	return "", nil
+}
+
+func (stat *whereStat) buildBetween(c *buildContext) (string, []interface{}) {
+	sql := fmt.Sprintf("%s %s ? and ?", c.ident(string(stat.column)), strings.ToLower(stat.operate))
+
+	if v, ok := stat.value.([]interface{}); ok && len(v) == 2 {
 		return sql, v
 	}
-	panic("between value must []interface{}")
+	c.addError(fmt.Errorf("%w: where between value must be min and max", ErrInvalidValue))
+	return "", nil
 }
 
-func (stat *whereStat) buildIn() (string, []interface{}) {
+func (stat *whereStat) buildIn(c *buildContext) (string, []interface{}) {
 	v := reflect.ValueOf(stat.value)
 	switch v.Kind() {
 	case reflect.Slice:
+		if v.Len() == 0 {
+			c.addError(fmt.Errorf("%w: where in value is empty", ErrInvalidValue))
+			return "", nil
+		}
 		data := make([]interface{}, v.Len())
 		replace := make([]string, v.Len())
 		for i := 0; i < v.Len(); i++ {
@@ -150,28 +337,25 @@ func (stat *whereStat) buildIn() (string, []interface{}) {
 				case string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
 					data[i] = inter
 				default:
-					panic("build in value is invalid")
+					c.addError(fmt.Errorf("%w: where in value %T is invalid", ErrInvalidValue, inter))
+					return "", nil
 				}
 			default:
 				data[i] = v.Index(i).String()
 			}
 		}
-		sql := fmt.Sprintf("%s in (%s)", stat.column, strings.Join(replace, ", "))
+		sql := fmt.Sprintf("%s %s (%s)", c.ident(string(stat.column)), strings.ToLower(stat.operate), strings.Join(replace, ", "))
 		return sql, data
 	case reflect.Func:
-		switch f := stat.value.(type) {
-		case func() Builder:
-			sql, data := f().Build()
-			return fmt.Sprintf("%s in (%s)", stat.column, sql), data
-		case BuilderFunc:
-			sql, data := f().Build()
-			return fmt.Sprintf("%s in (%s)", stat.column, sql), data
+		if sql, data, ok := buildSubquery(c, stat.value); ok {
+			return fmt.Sprintf("%s %s (%s)", c.ident(string(stat.column)), strings.ToLower(stat.operate), sql), data
 		}
-		panic("where func must BuilderFunc")
+		c.addError(fmt.Errorf("%w: where in func must be BuilderFunc", ErrInvalidValue))
+		return "", nil
 	default:
-		panic("where in value must slice")
+		c.addError(fmt.Errorf("%w: where in value must be slice or BuilderFunc", ErrInvalidValue))
+		return "", nil
 	}
-
 }
 
 func (builder *WhereBuilder) Where(column string, value interface{}) WhereInterface {
@@ -209,7 +393,7 @@ func (builder *WhereBuilder) WhereNotNull(column string) WhereInterface {
 // WhereOperate where column > value
 // 可以指定操作符的where语句
 func (builder *WhereBuilder) WhereOperate(column, operate string, value interface{}) WhereInterface {
-	builder.wh = append(builder.wh, &whereStat{
+	builder.conditions = append(builder.conditions, &whereStat{
 		column:  Column(column),
 		operate: operate,
 		value:   value,
@@ -217,20 +401,69 @@ func (builder *WhereBuilder) WhereOperate(column, operate string, value interfac
 	return builder
 }
 
+// WhereFunc where (...)
+// 条件分组，分组中有多个条件时加上括号
 func (builder *WhereBuilder) WhereFunc(f BuilderFunc) WhereInterface {
-	builder.wh = append(builder.wh, &whereStat{
+	builder.conditions = append(builder.conditions, &whereStat{
 		operate: "build",
 		value:   f(),
 	})
 	return builder
 }
 
+// WhereNotIn where column not in (...)
+func (builder *WhereBuilder) WhereNotIn(column string, value interface{}) WhereInterface {
+	return builder.WhereOperate(column, "not in", value)
+}
+
+// WhereNotBetween where column not between min and max
+func (builder *WhereBuilder) WhereNotBetween(column string, min, max interface{}) WhereInterface {
+	return builder.WhereOperate(column, "not between", []interface{}{min, max})
+}
+
+// WhereNotLike where column not like value
+func (builder *WhereBuilder) WhereNotLike(column string, value interface{}) WhereInterface {
+	return builder.WhereOperate(column, "not like", value)
+}
+
+// WhereNot where not (...)
+func (builder *WhereBuilder) WhereNot(f BuilderFunc) WhereInterface {
+	builder.conditions = append(builder.conditions, &whereStat{
+		operate: "not build",
+		value:   f(),
+	})
+	return builder
+}
+
+// WhereExists where exists (select ...)
+// 子查询中可以使用WhereColumn关联外部查询的字段
+func (builder *WhereBuilder) WhereExists(f BuilderFunc) WhereInterface {
+	return builder.WhereOperate("", "exists", f)
+}
+
+// WhereNotExists where not exists (select ...)
+func (builder *WhereBuilder) WhereNotExists(f BuilderFunc) WhereInterface {
+	return builder.WhereOperate("", "not exists", f)
+}
+
+// WhereAny where column > any (select ...)
+// value可以是BuilderFunc子查询，或者切片、driver.Valuer(如pq.Array)作为postgres的数组参数
+func (builder *WhereBuilder) WhereAny(column, operate string, value interface{}) WhereInterface {
+	return builder.WhereOperate(column, "any", &quantified{operate: operate, value: value})
+}
+
+// WhereAll where column > all (select ...)，value同WhereAny
+func (builder *WhereBuilder) WhereAll(column, operate string, value interface{}) WhereInterface {
+	return builder.WhereOperate(column, "all", &quantified{operate: operate, value: value})
+}
+
 func (builder *WhereBuilder) OrWhere(column string, value interface{}) WhereInterface {
 	return builder.OrWhereOperate(column, "=", value)
 }
 
 func (builder *WhereBuilder) OrWhereOperate(column, operate string, value interface{}) WhereInterface {
-	builder.orWh = append(builder.orWh, &whereStat{
+	builder.conditions = append(builder.conditions, &whereStat{
+		or:      true,
 		column:  Column(column),
 		operate: operate,
 		value:   value,
@@ -238,50 +471,170 @@ func (builder *WhereBuilder) OrWhereOperate(column, operate string, value interf
 	return builder
 }
 
+// OrWhereFunc or (...)
 func (builder *WhereBuilder) OrWhereFunc(f BuilderFunc) WhereInterface {
-	builder.orWh = append(builder.orWh, &whereStat{
+	builder.conditions = append(builder.conditions, &whereStat{
+		or:      true,
 		operate: "build",
 		value:   f(),
 	})
 	return builder
 }
 
+func (builder *WhereBuilder) OrWhereColumn(column1, column2 string) WhereInterface {
+	return builder.OrWhereOperate(column1, "=", Column(column2))
+}
+
+func (builder *WhereBuilder) OrWhereColumnOperate(column1, operate, column2 string) WhereInterface {
+	return builder.OrWhereOperate(column1, operate, Column(column2))
+}
+
+func (builder *WhereBuilder) OrWhereBetween(column string, min, max interface{}) WhereInterface {
+	return builder.OrWhereOperate(column, "between", []interface{}{min, max})
+}
+
+func (builder *WhereBuilder) OrWhereLike(column string, value interface{}) WhereInterface {
+	return builder.OrWhereOperate(column, "like", value)
+}
+
+func (builder *WhereBuilder) OrWhereIn(column string, value interface{}) WhereInterface {
+	return builder.OrWhereOperate(column, "in", value)
+}
+
+func (builder *WhereBuilder) OrWhereNull(column string) WhereInterface {
+	return builder.OrWhereOperate(column, "is", nil)
+}
+
+func (builder *WhereBuilder) OrWhereNotNull(column string) WhereInterface {
+	return builder.OrWhereOperate(column, "not", nil)
+}
+
+// OrWhereNotIn or column not in (...)
+func (builder *WhereBuilder) OrWhereNotIn(column string, value interface{}) WhereInterface {
+	return builder.OrWhereOperate(column, "not in", value)
+}
+
+// OrWhereNotBetween or column not between min and max
+func (builder *WhereBuilder) OrWhereNotBetween(column string, min, max interface{}) WhereInterface {
+	return builder.OrWhereOperate(column, "not between", []interface{}{min, max})
+}
+
+// OrWhereNotLike or column not like value
+func (builder *WhereBuilder) OrWhereNotLike(column string, value interface{}) WhereInterface {
+	return builder.OrWhereOperate(column, "not like", value)
+}
+
+// OrWhereNot or not (...)
+func (builder *WhereBuilder) OrWhereNot(f BuilderFunc) WhereInterface {
+	builder.conditions = append(builder.conditions, &whereStat{
+		or:      true,
+		operate: "not build",
+		value:   f(),
+	})
+	return builder
+}
+
+// OrWhereExists or exists (select ...)
+func (builder *WhereBuilder) OrWhereExists(f BuilderFunc) WhereInterface {
+	return builder.OrWhereOperate("", "exists", f)
+}
+
+// OrWhereNotExists or not exists (select ...)
+func (builder *WhereBuilder) OrWhereNotExists(f BuilderFunc) WhereInterface {
+	return builder.OrWhereOperate("", "not exists", f)
+}
+
+// OrWhereAny or column > any (select ...)
+func (builder *WhereBuilder) OrWhereAny(column, operate string, value interface{}) WhereInterface {
+	return builder.OrWhereOperate(column, "any", &quantified{operate: operate, value: value})
+}
+
+// OrWhereAll or column > all (select ...)
+func (builder *WhereBuilder) OrWhereAll(column, operate string, value interface{}) WhereInterface {
+	return builder.OrWhereOperate(column, "all", &quantified{operate: operate, value: value})
+}
+
+// Build 构建where语句，构建过程中有错误时返回空sql，错误可以通过BuildE或Err获取
 func (builder *WhereBuilder) Build() (string, []interface{}) {
-	sql := ""
-	data := make([]interface{}, 0)
-	if len(builder.wh) > 0 {
-		// builder where
-		for _, v := range builder.wh {
-			w, d := v.Build()
-			if w == "" {
-				break
-			}
-			if sql == "" {
-				sql = w
-				data = d
-			} else {
-				sql = fmt.Sprintf("%s and %s", sql, w)
-				data = append(data, d...)
-			}
-		}
+	sql, data, err := builder.buildE()
+	if err != nil {
+		return "", nil
+	}
+	return sql, data
+}
 
-		if len(builder.orWh) > 0 {
-			// build or where
-			for _, v := range builder.orWh {
-				w, d := v.Build()
-				if w == "" {
-					break
-				}
-				if sql == "" {
-					sql = w
-					data = d
-				} else {
-					sql = fmt.Sprintf("%s or %s", sql, w)
-					data = append(data, d...)
-				}
-			}
-		}
+// BuildE 构建where语句，构建过程中的错误将被返回
+func (builder *WhereBuilder) BuildE() (string, []interface{}, error) {
+	sql, data, err := builder.buildE()
+	if err != nil {
+		return "", nil, err
 	}
+	return sql, data, nil
+}
+
+// Err 返回构建where语句时的错误
+func (builder *WhereBuilder) Err() error {
+	_, _, err := builder.buildE()
+	return err
+}
+
+// Dialect 指定单独构建where语句时使用的方言，未指定时使用全局默认方言
+// 作为其他builder的条件构建时使用外层builder的方言
+func (builder *WhereBuilder) Dialect(d Dialect) *WhereBuilder {
+	builder.dialect = d
+	return builder
+}
+
+// QuoteIdentifier 指定单独构建where语句时是否引用字段名，未指定时使用全局设置
+// 作为其他builder的条件构建时使用外层builder的设置
+func (builder *WhereBuilder) QuoteIdentifier(quote bool) *WhereBuilder {
+	builder.quote = &quote
+	return builder
+}
+
+func (builder *WhereBuilder) buildE() (string, []interface{}, error) {
+	c := newBuildContext(builder.dialect, builder.quote)
+	sql, data := builder.build(c)
+	return rebind(c.dialect, sql), data, c.err()
+}
 
+func (builder *WhereBuilder) build(c *buildContext) (string, []interface{}) {
+	sql, data, _ := builder.buildConditions(c)
 	return sql, data
 }
+
+// buildConditions 按调用顺序构建条件，n为构建出的条件数量
+// 空的条件(如空的分组)将被跳过，第一个条件前的and、or将被忽略
+func (builder *WhereBuilder) buildConditions(c *buildContext) (sql string, data []interface{}, n int) {
+	data = make([]interface{}, 0)
+	for _, stat := range builder.conditions {
+		w, wd := stat.build(c)
+		if w == "" {
+			continue
+		}
+		switch {
+		case n == 0:
+			sql = w
+		case stat.or:
+			sql = fmt.Sprintf("%s or %s", sql, w)
+		default:
+			sql = fmt.Sprintf("%s and %s", sql, w)
+		}
+		data = append(data, wd...)
+		n++
+	}
+	return sql, data, n
+}
+
+// andConditions 使用and连接关联条件与where条件，条件不止一个时每个条件都加上括号，
+// 避免条件中的or(包括Raw中的OR)改变优先级
+func andConditions(conditions []string) string {
+	if len(conditions) == 1 {
+		return conditions[0]
+	}
+	wrapped := make([]string, len(conditions))
+	for i, condition := range conditions {
+		wrapped[i] = "(" + condition + ")"
+	}
+	return strings.Join(wrapped, " and ")
+}
@@ -0,0 +1,445 @@
+package sqlbuilder_test
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sureyee/sqlbuilder"
+)
+
+func TestWherePrecedence(t *testing.T) {
+	tests := []struct {
+		where sqlbuilder.WhereInterface
+		sql   string
+		data  []interface{}
+	}{
+		{
+			where: sqlbuilder.Where("a", 1).Where("b", 2),
+			sql:   "a = ? and b = ?",
+			data:  []interface{}{1, 2},
+		},
+		{
+			where: sqlbuilder.Where("a", 1).OrWhere("b", 2),
+			sql:   "a = ? or b = ?",
+			data:  []interface{}{1, 2},
+		},
+		{
+			// 按调用顺序构建，不再将or条件移到最后
+			where: sqlbuilder.Where("a", 1).OrWhere("b", 2).Where("c", 3),
+			sql:   "a = ? or b = ? and c = ?",
+			data:  []interface{}{1, 2, 3},
+		},
+		{
+			where: sqlbuilder.Where("a", 1).Where("b", 2).OrWhere("c", 3).Where("d", 4),
+			sql:   "a = ? and b = ? or c = ? and d = ?",
+			data:  []interface{}{1, 2, 3, 4},
+		},
+		{
+			// 只有or条件时不会被丢弃
+			where: (&sqlbuilder.WhereBuilder{}).OrWhere("a", 1).OrWhere("b", 2),
+			sql:   "a = ? or b = ?",
+			data:  []interface{}{1, 2},
+		},
+		{
+			where: (&sqlbuilder.WhereBuilder{}).OrWhere("a", 1).Where("b", 2),
+			sql:   "a = ? and b = ?",
+			data:  []interface{}{1, 2},
+		},
+		{
+			where: sqlbuilder.Where("a", 1).WhereFunc(func() sqlbuilder.Builder {
+				return sqlbuilder.Where("b", 2).OrWhere("c", 3)
+			}),
+			sql:  "a = ? and (b = ? or c = ?)",
+			data: []interface{}{1, 2, 3},
+		},
+		{
+			where: sqlbuilder.Where("a", 1).OrWhereFunc(func() sqlbuilder.Builder {
+				return sqlbuilder.Where("b", 2).Where("c", 3)
+			}),
+			sql:  "a = ? or (b = ? and c = ?)",
+			data: []interface{}{1, 2, 3},
+		},
+		{
+			// 分组中只有一个条件时不加括号
+			where: sqlbuilder.Where("a", 1).WhereFunc(func() sqlbuilder.Builder {
+				return sqlbuilder.Where("b", 2)
+			}),
+			sql:  "a = ? and b = ?",
+			data: []interface{}{1, 2},
+		},
+		{
+			// 只有or条件的分组
+			where: sqlbuilder.Where("a", 1).WhereFunc(func() sqlbuilder.Builder {
+				return (&sqlbuilder.WhereBuilder{}).OrWhere("b", 2).OrWhere("c", 3)
+			}),
+			sql:  "a = ? and (b = ? or c = ?)",
+			data: []interface{}{1, 2, 3},
+		},
+		{
+			// 多层嵌套
+			where: sqlbuilder.Where("a", 1).WhereFunc(func() sqlbuilder.Builder {
+				return sqlbuilder.Where("b", 2).OrWhereFunc(func() sqlbuilder.Builder {
+					return sqlbuilder.Where("c", 3).Where("d", 4)
+				})
+			}).OrWhere("e", 5),
+			sql:  "a = ? and (b = ? or (c = ? and d = ?)) or e = ?",
+			data: []interface{}{1, 2, 3, 4, 5},
+		},
+		{
+			// 空的分组被跳过，之后的条件不会丢失
+			where: sqlbuilder.Where("a", 1).WhereFunc(func() sqlbuilder.Builder {
+				return &sqlbuilder.WhereBuilder{}
+			}).OrWhere("b", 2),
+			sql:  "a = ? or b = ?",
+			data: []interface{}{1, 2},
+		},
+		{
+			// 分组在最前面
+			where: (&sqlbuilder.WhereBuilder{}).WhereFunc(func() sqlbuilder.Builder {
+				return sqlbuilder.Where("a", 1).OrWhere("b", 2)
+			}).Where("c", 3),
+			sql:  "(a = ? or b = ?) and c = ?",
+			data: []interface{}{1, 2, 3},
+		},
+		{
+			where: &sqlbuilder.WhereBuilder{},
+			sql:   "",
+			data:  []interface{}{},
+		},
+	}
+	for i, test := range tests {
+		sql, data := test.where.Build()
+		if sql != test.sql {
+			t.Errorf("case %d expected:`%v`, got:`%v`", i, test.sql, sql)
+		}
+		if !reflect.DeepEqual(data, test.data) {
+			t.Errorf("case %d expected:`%v`, got:`%v`", i, test.data, data)
+		}
+	}
+}
+
+func TestWherePrecedenceInSelect(t *testing.T) {
+	sql := "select * from users where status = ? or vip = ? and (age > ? or age is null)"
+	builderSql, _ := sqlbuilder.Select("*").From("users").
+		Where("status", 1).
+		OrWhere("vip", 1).
+		WhereFunc(func() sqlbuilder.Builder {
+			return sqlbuilder.WhereOperate("age", ">", 18).OrWhereFunc(func() sqlbuilder.Builder {
+				return sqlbuilder.WhereNull("age")
+			})
+		}).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestWhereNot(t *testing.T) {
+	tests := []struct {
+		where sqlbuilder.WhereInterface
+		sql   string
+		data  []interface{}
+	}{
+		{
+			where: (&sqlbuilder.WhereBuilder{}).WhereNotIn("id", []int{1, 2}),
+			sql:   "id not in (?, ?)",
+			data:  []interface{}{1, 2},
+		},
+		{
+			// 通过WhereOperate指定not in同样按切片构建
+			where: sqlbuilder.WhereOperate("id", "not in", []int{1, 2}),
+			sql:   "id not in (?, ?)",
+			data:  []interface{}{1, 2},
+		},
+		{
+			where: (&sqlbuilder.WhereBuilder{}).WhereNotIn("id", func() sqlbuilder.Builder {
+				return sqlbuilder.Select("user_id").From("blacklist").Where("status", 1)
+			}),
+			sql:  "id not in (select user_id from blacklist where status = ?)",
+			data: []interface{}{1},
+		},
+		{
+			where: sqlbuilder.Where("status", 1).WhereNotBetween("age", 18, 30),
+			sql:   "status = ? and age not between ? and ?",
+			data:  []interface{}{1, 18, 30},
+		},
+		{
+			where: sqlbuilder.Where("status", 1).OrWhereNotLike("name", "%test%"),
+			sql:   "status = ? or name not like ?",
+			data:  []interface{}{1, "%test%"},
+		},
+		{
+			where: sqlbuilder.Where("status", 1).OrWhereNotIn("id", []int{1}).OrWhereNotBetween("age", 1, 2),
+			sql:   "status = ? or id not in (?) or age not between ? and ?",
+			data:  []interface{}{1, 1, 1, 2},
+		},
+		{
+			where: sqlbuilder.Where("status", 1).WhereNot(func() sqlbuilder.Builder {
+				return sqlbuilder.Where("a", 1).OrWhere("b", 2)
+			}),
+			sql:  "status = ? and not (a = ? or b = ?)",
+			data: []interface{}{1, 1, 2},
+		},
+		{
+			where: sqlbuilder.Where("status", 1).OrWhereNot(func() sqlbuilder.Builder {
+				return sqlbuilder.Where("a", 1)
+			}),
+			sql:  "status = ? or not (a = ?)",
+			data: []interface{}{1, 1},
+		},
+		{
+			// 空的分组被跳过
+			where: sqlbuilder.Where("status", 1).WhereNot(func() sqlbuilder.Builder {
+				return &sqlbuilder.WhereBuilder{}
+			}),
+			sql:  "status = ?",
+			data: []interface{}{1},
+		},
+	}
+	for i, test := range tests {
+		sql, data := test.where.Build()
+		if sql != test.sql {
+			t.Errorf("case %d expected:`%v`, got:`%v`", i, test.sql, sql)
+		}
+		if !reflect.DeepEqual(data, test.data) {
+			t.Errorf("case %d expected:`%v`, got:`%v`", i, test.data, data)
+		}
+	}
+}
+
+func TestWhereNotInStatements(t *testing.T) {
+	sql := "select * from users where id not in ($1, $2) and age not between $3 and $4"
+	builderSql, _ := sqlbuilder.Select("*").From("users").
+		WhereNotIn("id", []int{1, 2}).
+		WhereNotBetween("age", 18, 30).
+		Dialect(sqlbuilder.PostgreSQL).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	sql = "update users set status = ? where name not like ? or not (age > ?)"
+	builderSql, _ = sqlbuilder.Update("users").Set("status", 0).
+		WhereNotLike("name", "admin%").
+		OrWhereNot(func() sqlbuilder.Builder {
+			return sqlbuilder.WhereOperate("age", ">", 18)
+		}).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	sql = "delete from users where id not in (?)"
+	builderSql, _ = sqlbuilder.Delete("users").WhereNotIn("id", []int{1}).Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestOrWhereFamily(t *testing.T) {
+	sql, data := sqlbuilder.Where("status", 1).
+		OrWhereIn("id", []int{1, 2}).
+		OrWhereBetween("age", 18, 30).
+		OrWhereLike("name", "zhang%").
+		OrWhereNull("deleted_at").
+		OrWhereNotNull("vip_at").
+		OrWhereColumn("created_at", "updated_at").
+		OrWhereColumnOperate("score", ">", "max_score").
+		Build()
+	expected := "status = ? or id in (?, ?) or age between ? and ? or name like ? or deleted_at is null " +
+		"or vip_at is not null or created_at = updated_at or score > max_score"
+	if sql != expected {
+		t.Errorf("expected:`%v`, got:`%v`", expected, sql)
+	}
+	if !reflect.DeepEqual(data, []interface{}{1, 1, 2, 18, 30, "zhang%"}) {
+		t.Errorf("unexpected data: %v", data)
+	}
+}
+
+func TestOrWhereFamilyStatements(t *testing.T) {
+	sql := "select * from users where status = ? or id in (?) or deleted_at is null or created_at = updated_at"
+	builderSql, _ := sqlbuilder.Select("*").From("users").
+		Where("status", 1).
+		OrWhereIn("id", []int{1}).
+		OrWhereNull("deleted_at").
+		OrWhereColumn("created_at", "updated_at").
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	sql = "update users set status = ? where age between ? and ? or name like ? or vip_at is not null"
+	builderSql, _ = sqlbuilder.Update("users").Set("status", 0).
+		OrWhereBetween("age", 1, 2).
+		OrWhereLike("name", "test%").
+		OrWhereNotNull("vip_at").
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	sql = "delete from users where score > max_score or score < min_score"
+	builderSql, _ = sqlbuilder.Delete("users").
+		WhereColumnOperate("score", ">", "max_score").
+		OrWhereColumnOperate("score", "<", "min_score").
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+}
+
+func TestWhereExists(t *testing.T) {
+	sql := "select * from users where status = $1 and exists (select 1 from orders where orders.user_id = users.id and orders.amount > $2) " +
+		"and not exists (select 1 from blacklist where blacklist.user_id = users.id) or exists (select 1 from vips where vips.user_id = users.id and vips.level = $3)"
+	builderSql, builderData := sqlbuilder.Select("*").From("users").
+		Where("status", 1).
+		WhereExists(func() sqlbuilder.Builder {
+			return sqlbuilder.Select("1").From("orders").WhereColumn("orders.user_id", "users.id").WhereOperate("orders.amount", ">", 100)
+		}).
+		WhereNotExists(func() sqlbuilder.Builder {
+			return sqlbuilder.Select("1").From("blacklist").WhereColumn("blacklist.user_id", "users.id")
+		}).
+		OrWhereExists(func() sqlbuilder.Builder {
+			return sqlbuilder.Select("1").From("vips").WhereColumn("vips.user_id", "users.id").Where("vips.level", 3)
+		}).
+		Dialect(sqlbuilder.PostgreSQL).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if !reflect.DeepEqual(builderData, []interface{}{1, 100, 3}) {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+
+	sql = "delete from users where id > ? or not exists (select 1 from orders where orders.user_id = users.id)"
+	builderSql, _ = sqlbuilder.Delete("users").
+		WhereOperate("id", ">", 10).
+		OrWhereNotExists(func() sqlbuilder.Builder {
+			return sqlbuilder.Select("1").From("orders").WhereColumn("orders.user_id", "users.id")
+		}).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	err := sqlbuilder.Select("*").From("users").WhereExists(nil).Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+}
+
+func TestWhereAnyAll(t *testing.T) {
+	sql := "select * from products where price > all (select price from products where category_id = ?) or id = any (select product_id from hot)"
+	builderSql, builderData := sqlbuilder.Select("*").From("products").
+		WhereAll("price", ">", func() sqlbuilder.Builder {
+			return sqlbuilder.Select("price").From("products").Where("category_id", 1)
+		}).
+		OrWhereAny("id", "=", func() sqlbuilder.Builder {
+			return sqlbuilder.Select("product_id").From("hot")
+		}).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if !reflect.DeepEqual(builderData, []interface{}{1}) {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+
+	// postgres 数组参数，需要driver.Valuer，如pq.Array
+	ids := int64Array{1, 2, 3}
+	sql = "select * from users where status = $1 and id = any ($2) and level <> all ($3)"
+	builderSql, builderData = sqlbuilder.Select("*").From("users").
+		Where("status", 1).
+		WhereAny("id", "=", ids).
+		WhereAll("level", "<>", int64Array{0, 9}).
+		Dialect(sqlbuilder.PostgreSQL).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+	if !reflect.DeepEqual(builderData, []interface{}{1, ids, int64Array{0, 9}}) {
+		t.Errorf("unexpected data: %v", builderData)
+	}
+
+	// postgres驱动无法直接编码go切片
+	err := sqlbuilder.Select("*").From("users").WhereAny("id", "=", []int{1, 2}).Dialect(sqlbuilder.PostgreSQL).Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+
+	// 不支持数组参数的方言中 = any 和 <> all 构建为 in 和 not in
+	sql = "select * from users where id in (?, ?) and level not in (?)"
+	builderSql, _ = sqlbuilder.Select("*").From("users").
+		WhereAny("id", "=", []int{1, 2}).
+		WhereAll("level", "<>", []int{0}).
+		Build()
+	if sql != builderSql {
+		t.Errorf("expected:`%v`, got:`%v`", sql, builderSql)
+	}
+
+	err = sqlbuilder.Select("*").From("users").WhereAny("id", ">", []int{1, 2}).Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+	}
+
+	err = sqlbuilder.Select("*").From("users").WhereAny("id", ">", func() sqlbuilder.Builder {
+		return sqlbuilder.Select("id").From("admins")
+	}).Dialect(sqlbuilder.SQLite).Err()
+	if !errors.Is(err, sqlbuilder.ErrUnsupportedFeature) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrUnsupportedFeature, err)
+	}
+
+	err = sqlbuilder.Select("*").From("users").WhereAny("id", "like", []int{1}).Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidOperator) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidOperator, err)
+	}
+
+	err = sqlbuilder.Select("*").From("users").WhereAll("id", ">", 1).Err()
+	if !errors.Is(err, sqlbuilder.ErrInvalidValue) {
+		t.Errorf("expected:`%v`, got:`%v`", sqlbuilder.ErrInvalidValue, err)
+	}
+}
+
+// int64Array 模拟pq.Array，以postgres数组字面量作为参数
+type int64Array []int64
+
+func (a int64Array) Value() (driver.Value, error) {
+	items := make([]string, len(a))
+	for i, v := range a {
+		items[i] = strconv.FormatInt(v, 10)
+	}
+	return "{" + strings.Join(items, ",") + "}", nil
+}
+
+func TestWhereOperators(t *testing.T) {
+	tests := []struct {
+		operate string
+		sql     string
+	}{
+		{"is not", "deleted_at is not ?"},
+		{"is distinct from", "deleted_at is distinct from ?"},
+		{"@>", "deleted_at @> ?"},
+		{"<@", "deleted_at <@ ?"},
+		{"&&", "deleted_at && ?"},
+		{"~*", "deleted_at ~* ?"},
+		{"similar to", "deleted_at similar to ?"},
+		{"<=>", "deleted_at <=> ?"},
+	}
+	for _, test := range tests {
+		builderSql, _, err := sqlbuilder.WhereOperate("deleted_at", test.operate, nil).BuildE()
+		if err != nil || test.sql != builderSql {
+			t.Errorf("expected:`%v`, got:`%v` %v", test.sql, builderSql, err)
+		}
+	}
+
+	for _, operate := range []string{"", "=;drop", "= 1 or 1 =", "--", "/*", "like'", "= ?", "is not;"} {
+		err := sqlbuilder.WhereOperate("deleted_at", operate, nil).Err()
+		if !errors.Is(err, sqlbuilder.ErrInvalidOperator) {
+			t.Errorf("%q expected:`%v`, got:`%v`", operate, sqlbuilder.ErrInvalidOperator, err)
+		}
+	}
+}